@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Aggregate owns one Registry per service and can roll them up into a single
+// combined Snapshot for the text printer and HTTP dashboard.
+type Aggregate struct {
+	mu       sync.Mutex
+	order    []string
+	services map[string]*Registry
+}
+
+// NewAggregate returns an Aggregate with a Registry pre-created for each
+// named service, preserving the given order for display.
+func NewAggregate(serviceNames []string) *Aggregate {
+	a := &Aggregate{
+		services: make(map[string]*Registry, len(serviceNames)),
+	}
+	for _, name := range serviceNames {
+		a.order = append(a.order, name)
+		a.services[name] = NewRegistry()
+	}
+	return a
+}
+
+// Service returns the Registry for a named service.
+func (a *Aggregate) Service(name string) *Registry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.services[name]
+}
+
+// ServiceSnapshot pairs a service name with its point-in-time Snapshot.
+type ServiceSnapshot struct {
+	Name     string
+	Snapshot Snapshot
+}
+
+// AggregateSnapshot is a per-service breakdown plus the rolled-up totals
+// across all services.
+type AggregateSnapshot struct {
+	Services []ServiceSnapshot
+	Total    Snapshot
+}
+
+// Snapshot returns the per-service snapshots (in registration order) along
+// with the combined totals.
+func (a *Aggregate) Snapshot() AggregateSnapshot {
+	a.mu.Lock()
+	names := append([]string(nil), a.order...)
+	registries := make(map[string]*Registry, len(a.services))
+	for name, r := range a.services {
+		registries[name] = r
+	}
+	a.mu.Unlock()
+
+	agg := AggregateSnapshot{
+		Services: make([]ServiceSnapshot, 0, len(names)),
+	}
+
+	for _, name := range names {
+		snap := registries[name].Snapshot()
+		agg.Services = append(agg.Services, ServiceSnapshot{Name: name, Snapshot: snap})
+
+		agg.Total.TotalCommits += snap.TotalCommits
+		agg.Total.QueuedCommits += snap.QueuedCommits
+		agg.Total.CompletedBuilds += snap.CompletedBuilds
+		agg.Total.AbortedBuilds += snap.AbortedBuilds
+		agg.Total.FailedBuilds += snap.FailedBuilds
+		agg.Total.Retries += snap.Retries
+		agg.Total.RecentBuilds = append(agg.Total.RecentBuilds, snap.RecentBuilds...)
+	}
+
+	return agg
+}
+
+// metricHeaders are the HELP/TYPE lines for each metric family, declared
+// once regardless of how many services report samples for them.
+var metricHeaders = []string{
+	"# HELP promoter_demo_commits_total Total number of commits generated by the simulation.",
+	"# TYPE promoter_demo_commits_total counter",
+	"# HELP promoter_demo_builds_completed_total Total number of builds that completed successfully.",
+	"# TYPE promoter_demo_builds_completed_total counter",
+	"# HELP promoter_demo_builds_aborted_total Total number of builds aborted by a newer commit.",
+	"# TYPE promoter_demo_builds_aborted_total counter",
+	"# HELP promoter_demo_builds_failed_total Total number of builds that failed (exhausted retries or hard-failed).",
+	"# TYPE promoter_demo_builds_failed_total counter",
+	"# HELP promoter_demo_build_retries_total Total number of transient build failures that were retried.",
+	"# TYPE promoter_demo_build_retries_total counter",
+	"# HELP promoter_demo_queue_depth Number of commits currently queued for a build.",
+	"# TYPE promoter_demo_queue_depth gauge",
+	"# HELP promoter_demo_build_duration_seconds Actual wall-clock time spent per build.",
+	"# TYPE promoter_demo_build_duration_seconds histogram",
+}
+
+// WriteMetrics renders every service's Registry as one Prometheus text
+// exposition document, with HELP/TYPE declared once per metric family and a
+// `service` label distinguishing each service's samples.
+func (a *Aggregate) WriteMetrics(w io.Writer) error {
+	a.mu.Lock()
+	names := append([]string(nil), a.order...)
+	registries := make(map[string]*Registry, len(a.services))
+	for name, r := range a.services {
+		registries[name] = r
+	}
+	a.mu.Unlock()
+
+	for _, h := range metricHeaders {
+		if _, err := fmt.Fprintln(w, h); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		if err := registries[name].WriteMetricSamples(w, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
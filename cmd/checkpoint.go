@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkpointRuntimes is the set of runtimes saveCheckpoint serializes. It's
+// set once in runSimulation after every runtime has been constructed (but
+// before any of their goroutines start), so a save triggered mid-simulation
+// always sees the full service list.
+var (
+	checkpointRuntimes []*serviceRuntime
+	checkpointMu       sync.Mutex
+)
+
+// commitEventDTO is CommitEvent's on-disk representation for --stateFile.
+// CommitEvent's fields are unexported since nothing outside
+// this package needs them; this DTO is kept separate rather than exporting
+// them just for serialization.
+type commitEventDTO struct {
+	Timestamp time.Time `json:"timestamp"`
+	ID        int       `json:"id"`
+	SHA       string    `json:"sha,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	Message   string    `json:"message,omitempty"`
+}
+
+func toDTO(c CommitEvent) commitEventDTO {
+	return commitEventDTO{Timestamp: c.timestamp, ID: c.id, SHA: c.sha, Author: c.author, Message: c.message}
+}
+
+func fromDTO(d commitEventDTO) CommitEvent {
+	return CommitEvent{timestamp: d.Timestamp, id: d.ID, sha: d.SHA, author: d.Author, message: d.Message}
+}
+
+// serviceCheckpoint is one service's slice of a SimulationCheckpoint.
+type serviceCheckpoint struct {
+	Name             string           `json:"name"`
+	NextCommitID     int              `json:"nextCommitID"`
+	QueuedCommits    []commitEventDTO `json:"queuedCommits"`
+	ActiveBuild      *commitEventDTO  `json:"activeBuild,omitempty"`
+	ActiveBuildStart *time.Time       `json:"activeBuildStart,omitempty"`
+	Stats            Snapshot         `json:"stats"`
+}
+
+// SimulationCheckpoint is the full --stateFile document.
+type SimulationCheckpoint struct {
+	SavedAt  time.Time           `json:"savedAt"`
+	Services []serviceCheckpoint `json:"services"`
+}
+
+// forService returns the saved state for a named service, or nil if the
+// checkpoint predates it (e.g. a service was added to --config since the
+// last save).
+func (cp *SimulationCheckpoint) forService(name string) *serviceCheckpoint {
+	if cp == nil {
+		return nil
+	}
+	for i := range cp.Services {
+		if cp.Services[i].Name == name {
+			return &cp.Services[i]
+		}
+	}
+	return nil
+}
+
+// buildCheckpoint snapshots every runtime's queued commits, in-flight build,
+// next commit ID, and stats registry.
+func buildCheckpoint(runtimes []*serviceRuntime) SimulationCheckpoint {
+	cp := SimulationCheckpoint{SavedAt: time.Now()}
+
+	for _, rt := range runtimes {
+		queued, active, nextID := rt.checkpointState()
+
+		queuedDTOs := make([]commitEventDTO, len(queued))
+		for i, c := range queued {
+			queuedDTOs[i] = toDTO(c)
+		}
+
+		var activeDTO *commitEventDTO
+		if active != nil {
+			d := toDTO(*active)
+			activeDTO = &d
+		}
+
+		snap := rt.stats.Snapshot()
+		var activeStart *time.Time
+		if snap.CurrentBuildStartTime != nil {
+			t := *snap.CurrentBuildStartTime
+			activeStart = &t
+		}
+
+		cp.Services = append(cp.Services, serviceCheckpoint{
+			Name:             rt.name,
+			NextCommitID:     nextID,
+			QueuedCommits:    queuedDTOs,
+			ActiveBuild:      activeDTO,
+			ActiveBuildStart: activeStart,
+			Stats:            snap,
+		})
+	}
+
+	return cp
+}
+
+// saveCheckpoint writes the current state of checkpointRuntimes to --stateFile
+// via an atomic write-and-rename, so a crash mid-write never leaves a
+// truncated file behind. It's a no-op until --stateFile and checkpointRuntimes
+// are both set, and is cheap enough to call after every queue/build
+// transition since simulated builds complete on the order of seconds/minutes.
+func saveCheckpoint() {
+	if stateFile == "" || len(checkpointRuntimes) == 0 {
+		return
+	}
+
+	checkpointMu.Lock()
+	defer checkpointMu.Unlock()
+
+	cp := buildCheckpoint(checkpointRuntimes)
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		fmt.Printf("⚠️  Could not marshal --stateFile checkpoint: %v\n", err)
+		return
+	}
+
+	tmp := stateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		fmt.Printf("⚠️  Could not write --stateFile checkpoint: %v\n", err)
+		return
+	}
+	if err := os.Rename(tmp, stateFile); err != nil {
+		fmt.Printf("⚠️  Could not finalize --stateFile checkpoint: %v\n", err)
+	}
+}
+
+// loadCheckpoint reads a --stateFile checkpoint, returning (nil, nil) if it
+// doesn't exist yet (a fresh run).
+func loadCheckpoint(path string) (*SimulationCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --stateFile: %w", err)
+	}
+
+	var cp SimulationCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse --stateFile: %w", err)
+	}
+	return &cp, nil
+}
+
+// applyCheckpoint rehydrates rt from a saved serviceCheckpoint: restores
+// stats, re-queues pending commits, and arranges for processBuildQueue to
+// resume any in-flight build with its remaining duration (computed from the
+// saved start time and buildDuration) rather than starting a fresh one.
+func applyCheckpoint(rt *serviceRuntime, sc *serviceCheckpoint, buildDuration time.Duration) {
+	if sc == nil {
+		return
+	}
+
+	rt.resumed = true
+	rt.stats.Restore(sc.Stats)
+	rt.setNextCommitID(sc.NextCommitID)
+
+	for _, d := range sc.QueuedCommits {
+		c := fromDTO(d)
+		rt.commitQueue <- c
+		rt.enqueueSnapshot(c)
+	}
+
+	if sc.ActiveBuild == nil {
+		return
+	}
+
+	commit := fromDTO(*sc.ActiveBuild)
+	start := time.Now()
+	if sc.ActiveBuildStart != nil {
+		start = *sc.ActiveBuildStart
+	}
+
+	remaining := buildDuration - time.Since(start)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	rt.pendingResume = &resumedBuild{Commit: commit, OriginalStart: start, Remaining: remaining}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func newTestRuntime(t *testing.T, name string) *serviceRuntime {
+	t.Helper()
+	fm, err := newFailureModel(0, "", "", 3)
+	if err != nil {
+		t.Fatalf("newFailureModel: %v", err)
+	}
+	rt, err := newServiceRuntime(ServiceConfig{
+		Name:                      name,
+		ManifestKustomizeFilePath: "unused.yaml",
+		SimulatedBuildDuration:    "1m",
+		SimulatedCommitRate:       "1m",
+	}, make(chan struct{}, 1), fm, NewRegistry())
+	if err != nil {
+		t.Fatalf("newServiceRuntime: %v", err)
+	}
+	return rt
+}
+
+// TestCheckpointRoundTrip verifies that buildCheckpoint -> JSON -> applyCheckpoint
+// restores a runtime's queued commits, in-flight build, and next commit ID.
+func TestCheckpointRoundTrip(t *testing.T) {
+	rt := newTestRuntime(t, "svc1")
+
+	queued := []CommitEvent{
+		{id: 2, timestamp: time.Now(), sha: "aaa"},
+		{id: 3, timestamp: time.Now(), sha: "bbb"},
+	}
+	for _, c := range queued {
+		rt.commitQueue <- c
+		rt.enqueueSnapshot(c)
+	}
+	rt.setNextCommitID(4)
+
+	activeBuild := CommitEvent{id: 1, timestamp: time.Now(), sha: "ccc"}
+	buildStart := time.Now().Add(-30 * time.Second)
+	rt.setActiveBuild(&activeBuild)
+	rt.stats.StartBuild(activeBuild.id, buildStart)
+
+	cp := buildCheckpoint([]*serviceRuntime{rt})
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		t.Fatalf("marshal checkpoint: %v", err)
+	}
+	var cp2 SimulationCheckpoint
+	if err := json.Unmarshal(data, &cp2); err != nil {
+		t.Fatalf("unmarshal checkpoint: %v", err)
+	}
+
+	rt2 := newTestRuntime(t, "svc1")
+	buildDuration := time.Minute
+	applyCheckpoint(rt2, cp2.forService("svc1"), buildDuration)
+
+	if !rt2.resumed {
+		t.Fatal("applyCheckpoint did not mark the runtime as resumed")
+	}
+
+	// applyCheckpoint re-queues pending commits both onto rt2.commitQueue and
+	// into the enqueueSnapshot record that checkpointState reads, so check both.
+	gotQueued, _, gotNextID := rt2.checkpointState()
+	if gotNextID != 4 {
+		t.Errorf("nextCommitID = %d, want 4", gotNextID)
+	}
+	if len(gotQueued) != len(queued) {
+		t.Fatalf("got %d queued commits in the resumable-state snapshot, want %d", len(gotQueued), len(queued))
+	}
+	for i, c := range gotQueued {
+		if c.id != queued[i].id || c.sha != queued[i].sha {
+			t.Errorf("queued commit %d = %+v, want id=%d sha=%s", i, c, queued[i].id, queued[i].sha)
+		}
+	}
+	if len(rt2.commitQueue) != len(queued) {
+		t.Fatalf("commitQueue has %d pending commits, want %d", len(rt2.commitQueue), len(queued))
+	}
+
+	// The in-flight build is not restored via setActiveBuild here; applyCheckpoint
+	// leaves that to processBuildQueue, which resumes pendingResume itself.
+	if rt2.pendingResume == nil {
+		t.Fatal("applyCheckpoint did not set pendingResume for the in-flight build")
+	}
+	if rt2.pendingResume.Commit.id != activeBuild.id {
+		t.Errorf("pendingResume.Commit.id = %d, want %d", rt2.pendingResume.Commit.id, activeBuild.id)
+	}
+	wantRemaining := buildDuration - 30*time.Second
+	if diff := rt2.pendingResume.Remaining - wantRemaining; diff < -2*time.Second || diff > 2*time.Second {
+		t.Errorf("pendingResume.Remaining = %v, want ~%v", rt2.pendingResume.Remaining, wantRemaining)
+	}
+}
+
+// TestApplyCheckpointNilIsNoOp verifies that applying a nil checkpoint (a
+// service absent from an older --stateFile) leaves a fresh runtime untouched.
+func TestApplyCheckpointNilIsNoOp(t *testing.T) {
+	rt := newTestRuntime(t, "svc1")
+	applyCheckpoint(rt, nil, time.Minute)
+
+	if rt.resumed {
+		t.Error("applyCheckpoint(nil) should not mark the runtime as resumed")
+	}
+	if rt.pendingResume != nil {
+		t.Error("applyCheckpoint(nil) should not set pendingResume")
+	}
+}
+
+// TestApplyCheckpointExpiredBuildHasZeroRemaining verifies that a build whose
+// saved start time is already past buildDuration resumes with no remaining
+// time left, rather than a negative duration.
+func TestApplyCheckpointExpiredBuildHasZeroRemaining(t *testing.T) {
+	rt := newTestRuntime(t, "svc1")
+	active := CommitEvent{id: 1, sha: "ccc"}
+	rt.setActiveBuild(&active)
+	rt.stats.StartBuild(active.id, time.Now().Add(-5*time.Minute))
+
+	cp := buildCheckpoint([]*serviceRuntime{rt})
+
+	rt2 := newTestRuntime(t, "svc1")
+	applyCheckpoint(rt2, cp.forService("svc1"), time.Minute)
+
+	if rt2.pendingResume == nil {
+		t.Fatal("expected a pendingResume for the expired build")
+	}
+	if rt2.pendingResume.Remaining != 0 {
+		t.Errorf("Remaining = %v, want 0 for a build whose duration already elapsed", rt2.pendingResume.Remaining)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultMaxParallelBuilds is used when neither --maxParallelBuilds nor the
+// config file specifies one.
+const defaultMaxParallelBuilds = 4
+
+// ServiceConfig describes one independently-simulated service: its own
+// manifest, build duration, commit-rate spec, and abort policy. Multiple
+// services can point at the same manifest directory (e.g. a mono-repo);
+// bumpManifestVersion/gitCommitAndPush serialize on that directory so
+// concurrent services never race on the same git working tree.
+type ServiceConfig struct {
+	Name                      string `yaml:"name"`
+	ManifestKustomizeFilePath string `yaml:"manifestKustomizeFilePath"`
+	SimulatedBuildDuration    string `yaml:"simulatedBuildDuration"`
+	SimulatedCommitRate       string `yaml:"simulatedCommitRate"`
+	AbortOnNewCommit          bool   `yaml:"abortOnNewCommit"`
+}
+
+// SimConfig is the top-level `--config sim.yaml` document describing a
+// multi-service simulation.
+type SimConfig struct {
+	Services          []ServiceConfig `yaml:"services"`
+	MaxParallelBuilds int             `yaml:"maxParallelBuilds,omitempty"`
+}
+
+// loadSimConfig reads and validates a multi-service simulation config.
+func loadSimConfig(path string) (*SimConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg SimConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if len(cfg.Services) == 0 {
+		return nil, fmt.Errorf("config must define at least one service")
+	}
+
+	seen := make(map[string]bool, len(cfg.Services))
+	for i := range cfg.Services {
+		svc := &cfg.Services[i]
+		if svc.Name == "" {
+			return nil, fmt.Errorf("service at index %d is missing a name", i)
+		}
+		if seen[svc.Name] {
+			return nil, fmt.Errorf("duplicate service name: %s", svc.Name)
+		}
+		seen[svc.Name] = true
+
+		if svc.ManifestKustomizeFilePath == "" {
+			return nil, fmt.Errorf("service %q is missing manifestKustomizeFilePath", svc.Name)
+		}
+		if _, err := os.Stat(svc.ManifestKustomizeFilePath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("service %q: manifest file does not exist: %s", svc.Name, svc.ManifestKustomizeFilePath)
+		}
+		if svc.SimulatedBuildDuration == "" {
+			svc.SimulatedBuildDuration = simulatedBuildDuration
+		}
+		if svc.SimulatedCommitRate == "" {
+			svc.SimulatedCommitRate = simulatedCommitRate
+		}
+	}
+
+	if cfg.MaxParallelBuilds <= 0 {
+		cfg.MaxParallelBuilds = maxParallelBuilds
+	}
+	if cfg.MaxParallelBuilds <= 0 {
+		cfg.MaxParallelBuilds = defaultMaxParallelBuilds
+	}
+
+	return &cfg, nil
+}
+
+// singleServiceConfig synthesizes a one-service SimConfig from the legacy
+// top-level flags, so `--manifestKustomizeFilePath` keeps working without
+// `--config`.
+func singleServiceConfig() *SimConfig {
+	maxParallel := maxParallelBuilds
+	if maxParallel <= 0 {
+		maxParallel = defaultMaxParallelBuilds
+	}
+
+	return &SimConfig{
+		MaxParallelBuilds: maxParallel,
+		Services: []ServiceConfig{
+			{
+				Name:                      "default",
+				ManifestKustomizeFilePath: manifestKustomizeFilePath,
+				SimulatedBuildDuration:    simulatedBuildDuration,
+				SimulatedCommitRate:       simulatedCommitRate,
+				AbortOnNewCommit:          abortOnNewCommit,
+			},
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestManifest creates a throwaway manifest file loadSimConfig's
+// os.Stat check requires to exist, returning its path.
+func writeTestManifest(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "kustomization.yaml")
+	if err := os.WriteFile(path, []byte("resources: []\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+// TestLoadSimConfigFallsBackToMaxParallelBuildsFlag verifies that a config
+// file which omits maxParallelBuilds falls back to the --maxParallelBuilds
+// flag value, not straight to defaultMaxParallelBuilds, so --config and
+// --maxParallelBuilds can be combined.
+func TestLoadSimConfigFallsBackToMaxParallelBuildsFlag(t *testing.T) {
+	manifest := writeTestManifest(t)
+	configPath := filepath.Join(t.TempDir(), "sim.yaml")
+	yamlBody := "services:\n  - name: svc1\n    manifestKustomizeFilePath: " + manifest + "\n"
+	if err := os.WriteFile(configPath, []byte(yamlBody), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	oldFlag := maxParallelBuilds
+	maxParallelBuilds = 10
+	defer func() { maxParallelBuilds = oldFlag }()
+
+	cfg, err := loadSimConfig(configPath)
+	if err != nil {
+		t.Fatalf("loadSimConfig: %v", err)
+	}
+	if cfg.MaxParallelBuilds != 10 {
+		t.Errorf("MaxParallelBuilds = %d, want 10 (the --maxParallelBuilds flag value)", cfg.MaxParallelBuilds)
+	}
+}
+
+// TestLoadSimConfigMaxParallelBuildsFallsBackToDefault verifies that with
+// neither the config file nor the --maxParallelBuilds flag set, loadSimConfig
+// still falls back to defaultMaxParallelBuilds.
+func TestLoadSimConfigMaxParallelBuildsFallsBackToDefault(t *testing.T) {
+	manifest := writeTestManifest(t)
+	configPath := filepath.Join(t.TempDir(), "sim.yaml")
+	yamlBody := "services:\n  - name: svc1\n    manifestKustomizeFilePath: " + manifest + "\n"
+	if err := os.WriteFile(configPath, []byte(yamlBody), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	oldFlag := maxParallelBuilds
+	maxParallelBuilds = 0
+	defer func() { maxParallelBuilds = oldFlag }()
+
+	cfg, err := loadSimConfig(configPath)
+	if err != nil {
+		t.Fatalf("loadSimConfig: %v", err)
+	}
+	if cfg.MaxParallelBuilds != defaultMaxParallelBuilds {
+		t.Errorf("MaxParallelBuilds = %d, want %d", cfg.MaxParallelBuilds, defaultMaxParallelBuilds)
+	}
+}
+
+// TestLoadSimConfigMaxParallelBuildsFromYAMLWins verifies that an explicit
+// maxParallelBuilds in the config file takes precedence over the flag.
+func TestLoadSimConfigMaxParallelBuildsFromYAMLWins(t *testing.T) {
+	manifest := writeTestManifest(t)
+	configPath := filepath.Join(t.TempDir(), "sim.yaml")
+	yamlBody := "maxParallelBuilds: 7\nservices:\n  - name: svc1\n    manifestKustomizeFilePath: " + manifest + "\n"
+	if err := os.WriteFile(configPath, []byte(yamlBody), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	oldFlag := maxParallelBuilds
+	maxParallelBuilds = 10
+	defer func() { maxParallelBuilds = oldFlag }()
+
+	cfg, err := loadSimConfig(configPath)
+	if err != nil {
+		t.Fatalf("loadSimConfig: %v", err)
+	}
+	if cfg.MaxParallelBuilds != 7 {
+		t.Errorf("MaxParallelBuilds = %d, want 7 (the config file value)", cfg.MaxParallelBuilds)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EventType identifies what happened in an Event.
+type EventType string
+
+const (
+	EventCommit        EventType = "commit"
+	EventBuildStart    EventType = "build_start"
+	EventBuildComplete EventType = "build_complete"
+	EventBuildAbort    EventType = "build_abort"
+	EventManifestPush  EventType = "manifest_push"
+)
+
+// Event is one simulation occurrence published through an EventSink, so
+// external systems can react to the simulation instead of scraping stdout.
+type Event struct {
+	Type        EventType `json:"type"`
+	ServiceName string    `json:"serviceName"`
+	CommitID    int       `json:"commitID,omitempty"`
+	SHA         string    `json:"sha,omitempty"`
+	Version     string    `json:"version,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// EventSink delivers Events to some external system. Implementations are
+// expected to handle their own retries; Publish errors are logged by
+// bufferedSink rather than propagated, since a down consumer should never
+// stop the simulation.
+type EventSink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// eventSinkBuffer is the process-wide sink, set up in runSimulation from
+// --eventSink. nil when --eventSink is unset (the default), in which case
+// emitEvent is a no-op.
+var eventSinkBuffer *bufferedSink
+
+// emitEvent publishes e through the configured --eventSink, if any.
+func emitEvent(e Event) {
+	if eventSinkBuffer == nil {
+		return
+	}
+	e.Timestamp = time.Now()
+	eventSinkBuffer.enqueue(e)
+}
+
+// bufferedSink wraps an EventSink with a bounded in-memory buffer and a
+// drop-oldest policy, so a slow or unreachable consumer can never block the
+// simulation's goroutines on a full channel.
+type bufferedSink struct {
+	sink EventSink
+
+	mu  sync.Mutex
+	buf chan Event
+}
+
+// newBufferedSink starts a background goroutine that delivers buffered
+// events to sink one at a time, in order.
+func newBufferedSink(sink EventSink, capacity int) *bufferedSink {
+	if capacity <= 0 {
+		capacity = 256
+	}
+	b := &bufferedSink{sink: sink, buf: make(chan Event, capacity)}
+	go b.deliver()
+	return b
+}
+
+// enqueue adds e to the buffer, dropping the oldest buffered event if full.
+func (b *bufferedSink) enqueue(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	select {
+	case b.buf <- e:
+		return
+	default:
+	}
+
+	// Buffer full: drop the oldest event to make room for this one.
+	select {
+	case <-b.buf:
+	default:
+	}
+	select {
+	case b.buf <- e:
+	default:
+	}
+}
+
+func (b *bufferedSink) deliver() {
+	for e := range b.buf {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := b.sink.Publish(ctx, e); err != nil {
+			fmt.Printf("⚠️  [eventsink] publish failed: %v\n", err)
+		}
+		cancel()
+	}
+}
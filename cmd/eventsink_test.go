@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// noopSink discards every event; bufferedSink's delivery goroutine isn't
+// started in these tests, so it's never actually invoked.
+type noopSink struct{}
+
+func (noopSink) Publish(ctx context.Context, event Event) error { return nil }
+
+// TestBufferedSinkEnqueueDropsOldestWhenFull verifies enqueue's drop-oldest
+// policy: once the buffer is full, the oldest queued event is discarded to
+// make room for the newest one rather than blocking the caller.
+func TestBufferedSinkEnqueueDropsOldestWhenFull(t *testing.T) {
+	b := &bufferedSink{sink: noopSink{}, buf: make(chan Event, 2)}
+
+	b.enqueue(Event{CommitID: 1})
+	b.enqueue(Event{CommitID: 2})
+	b.enqueue(Event{CommitID: 3}) // buffer full: should drop CommitID 1
+
+	close(b.buf)
+	var got []int
+	for e := range b.buf {
+		got = append(got, e.CommitID)
+	}
+
+	want := []int{2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+}
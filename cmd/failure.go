@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// buildOutcome is the result of simulating one build attempt.
+type buildOutcome int
+
+const (
+	outcomeSuccess buildOutcome = iota
+	outcomeTransientFailure
+	outcomeHardFailure
+)
+
+// trailerStatus is what gets written into the
+// Argocd-reference-commit-build-status git trailer.
+func (o buildOutcome) trailerStatus() string {
+	switch o {
+	case outcomeTransientFailure:
+		return "retried"
+	case outcomeHardFailure:
+		return "failed"
+	default:
+		return "success"
+	}
+}
+
+// infraOutageChancePerBuild is the probability, each time a build starts
+// under the "infra-outage" pattern, that a fresh outage window begins.
+const infraOutageChancePerBuild = 0.05
+
+// FailureModel simulates real-world build outcomes: transient flakiness,
+// hard failures, and infrastructure outages. It is shared across every
+// service's serviceRuntime since an "infra-outage" is a simulation-wide
+// event, not a per-service one.
+type FailureModel struct {
+	rate       float64
+	pattern    string // "", "flaky", "bimodal", "infra-outage"
+	jitterFrac float64
+	maxRetries int
+
+	mu          sync.Mutex
+	outageUntil time.Time
+}
+
+// newFailureModel builds a FailureModel from the --buildFailureRate,
+// --buildFailurePattern, --buildDurationJitter, and --maxRetries flags.
+func newFailureModel(rate float64, pattern, jitterSpec string, maxRetries int) (*FailureModel, error) {
+	jitterFrac, err := parseJitterSpec(jitterSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FailureModel{
+		rate:       rate,
+		pattern:    pattern,
+		jitterFrac: jitterFrac,
+		maxRetries: maxRetries,
+	}, nil
+}
+
+// parseJitterSpec parses a spec like "±20%" or "20%" into a fraction (0.2).
+func parseJitterSpec(spec string) (float64, error) {
+	if spec == "" {
+		return 0, nil
+	}
+	s := strings.TrimPrefix(spec, "±")
+	s = strings.TrimSuffix(s, "%")
+	pct, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid buildDurationJitter %q: %w", spec, err)
+	}
+	return pct / 100, nil
+}
+
+// jitteredDuration applies ±jitterFrac random variance to base.
+func (fm *FailureModel) jitteredDuration(base time.Duration) time.Duration {
+	if fm == nil || fm.jitterFrac <= 0 {
+		return base
+	}
+	delta := (rand.Float64()*2 - 1) * fm.jitterFrac
+	d := time.Duration(float64(base) * (1 + delta))
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// effectiveDuration returns the jittered build duration, possibly shortened
+// to model an infra outage "failing fast" instead of running the full build.
+func (fm *FailureModel) effectiveDuration(base time.Duration) time.Duration {
+	if fm == nil {
+		return base
+	}
+	d := fm.jitteredDuration(base)
+	if fm.pattern == "infra-outage" {
+		fm.maybeTriggerOutage()
+		if fm.infraOutageActive() {
+			const fastFail = 5 * time.Second
+			if d > fastFail {
+				d = fastFail
+			}
+		}
+	}
+	return d
+}
+
+func (fm *FailureModel) infraOutageActive() bool {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	return !fm.outageUntil.IsZero() && time.Now().Before(fm.outageUntil)
+}
+
+// maybeTriggerOutage has a small chance of starting a new 5-20 minute outage
+// window, unless one is already in progress.
+func (fm *FailureModel) maybeTriggerOutage() {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	if !fm.outageUntil.IsZero() && time.Now().Before(fm.outageUntil) {
+		return
+	}
+	if rand.Float64() >= infraOutageChancePerBuild {
+		return
+	}
+
+	dur := time.Duration(5+rand.Intn(16)) * time.Minute
+	fm.outageUntil = time.Now().Add(dur)
+	fmt.Printf("🔥 Infra outage triggered, lasting %v\n", dur.Round(time.Second))
+}
+
+// sample rolls one build attempt's outcome.
+func (fm *FailureModel) sample() buildOutcome {
+	if fm == nil || fm.rate <= 0 {
+		return outcomeSuccess
+	}
+
+	if fm.pattern == "infra-outage" && fm.infraOutageActive() {
+		return outcomeHardFailure
+	}
+
+	if rand.Float64() >= fm.rate {
+		return outcomeSuccess
+	}
+
+	if fm.pattern == "bimodal" {
+		// Once broken, bimodal failures don't recover mid-build; no point retrying.
+		return outcomeHardFailure
+	}
+	return outcomeTransientFailure
+}
+
+// resolve simulates a build attempt, retrying transient failures with
+// exponential backoff up to maxRetries, and reports the retries it made to
+// stats. It returns the final outcome and the number of attempts taken; a
+// build that succeeds after one or more retries reports outcomeTransientFailure
+// ("retried") rather than outcomeSuccess, so the retry is visible in the
+// commit trailer.
+func (fm *FailureModel) resolve(stats *Registry) (buildOutcome, int) {
+	attempts := 0
+	retried := false
+	for {
+		attempts++
+		outcome := fm.sample()
+		if outcome == outcomeSuccess {
+			if retried {
+				return outcomeTransientFailure, attempts
+			}
+			return outcome, attempts
+		}
+		if outcome == outcomeHardFailure {
+			return outcome, attempts
+		}
+
+		maxRetries := 0
+		if fm != nil {
+			maxRetries = fm.maxRetries
+		}
+		if attempts > maxRetries {
+			return outcomeHardFailure, attempts
+		}
+
+		retried = true
+		stats.RecordRetry()
+		backoff := time.Duration(1<<uint(attempts-1)) * time.Second
+		time.Sleep(backoff)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParseJitterSpec(t *testing.T) {
+	cases := []struct {
+		spec    string
+		want    float64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"20%", 0.2, false},
+		{"±20%", 0.2, false},
+		{"0%", 0, false},
+		{"not-a-number", 0, true},
+	}
+
+	for _, c := range cases {
+		got, err := parseJitterSpec(c.spec)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseJitterSpec(%q): expected an error, got none", c.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseJitterSpec(%q): unexpected error: %v", c.spec, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("parseJitterSpec(%q) = %v, want %v", c.spec, got, c.want)
+		}
+	}
+}
+
+func TestFailureModelResolveBimodalIsHardFailureWithoutRetry(t *testing.T) {
+	fm, err := newFailureModel(1, "bimodal", "", 3)
+	if err != nil {
+		t.Fatalf("newFailureModel: %v", err)
+	}
+
+	outcome, attempts := fm.resolve(NewRegistry())
+	if outcome != outcomeHardFailure {
+		t.Errorf("outcome = %v, want outcomeHardFailure", outcome)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1 (bimodal failures don't retry)", attempts)
+	}
+}
+
+func TestFailureModelResolveFlakyExhaustsRetries(t *testing.T) {
+	fm, err := newFailureModel(1, "flaky", "", 1)
+	if err != nil {
+		t.Fatalf("newFailureModel: %v", err)
+	}
+
+	stats := NewRegistry()
+	outcome, attempts := fm.resolve(stats)
+	if outcome != outcomeHardFailure {
+		t.Errorf("outcome = %v, want outcomeHardFailure once maxRetries is exhausted", outcome)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2 (1 initial + 1 retry)", attempts)
+	}
+	if got := stats.Snapshot().Retries; got != 1 {
+		t.Errorf("recorded retries = %d, want 1", got)
+	}
+}
+
+func TestFailureModelResolveSuccessAfterRetryReportsRetried(t *testing.T) {
+	fm, err := newFailureModel(0.5, "flaky", "", 3)
+	if err != nil {
+		t.Fatalf("newFailureModel: %v", err)
+	}
+
+	// Seed 6 makes the first sample() draw fail and the second succeed, so
+	// this exercises the "failed once, then succeeded on retry" path
+	// deterministically.
+	rand.Seed(6)
+
+	stats := NewRegistry()
+	outcome, attempts := fm.resolve(stats)
+	if outcome != outcomeTransientFailure {
+		t.Errorf("outcome = %v, want outcomeTransientFailure (\"retried\") for a build that succeeded after a retry", outcome)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2 (1 failure + 1 successful retry)", attempts)
+	}
+	if got := stats.Snapshot().Retries; got != 1 {
+		t.Errorf("recorded retries = %d, want 1", got)
+	}
+}
+
+func TestFailureModelResolveNoFailureRateAlwaysSucceeds(t *testing.T) {
+	fm, err := newFailureModel(0, "", "", 3)
+	if err != nil {
+		t.Fatalf("newFailureModel: %v", err)
+	}
+
+	outcome, attempts := fm.resolve(NewRegistry())
+	if outcome != outcomeSuccess {
+		t.Errorf("outcome = %v, want outcomeSuccess", outcome)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestBuildOutcomeTrailerStatus(t *testing.T) {
+	cases := []struct {
+		outcome buildOutcome
+		want    string
+	}{
+		{outcomeSuccess, "success"},
+		{outcomeTransientFailure, "retried"},
+		{outcomeHardFailure, "failed"},
+	}
+	for _, c := range cases {
+		if got := c.outcome.trailerStatus(); got != c.want {
+			t.Errorf("trailerStatus(%v) = %q, want %q", c.outcome, got, c.want)
+		}
+	}
+}
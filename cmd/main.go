@@ -14,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -26,23 +27,64 @@ var (
 	simulatedCommitRate       string
 	manifestKustomizeFilePath string
 	skipGitOperations         bool
+	httpAddr                  string
+	configFilePath            string
+	maxParallelBuilds         int
+	buildFailureRate          float64
+	buildFailurePattern       string
+	buildDurationJitter       string
+	maxRetries                int
+	commitSource              string
+	upstreamRepo              string
+	upstreamBranch            string
+	pollInterval              time.Duration
+	cacheDir                  string
+	stateFile                 string
+	seed                      int64
+	replayLog                 string
+	replayFrom                string
+	replaySpeed               float64
+	eventSinkKind             string
+	webhookURL                string
+	webhookSecret             string
+	natsURL                   string
+	natsSubject               string
+	amqpURL                   string
+	amqpExchange              string
 	commitSHAsCache           []string
 	commitSHAsMutex           sync.Mutex
+
+	// manifestDirMutexes serializes bumpManifestVersion/gitCommitAndPush per
+	// manifest directory, since multiple services in a --config run may
+	// share a repo and must not race on `git add`/`git commit`/`git push`.
+	manifestDirMutexes   = map[string]*sync.Mutex{}
+	manifestDirMutexesMu sync.Mutex
 )
 
-type SimulationStats struct {
-	mu                    sync.Mutex
-	totalCommits          int
-	queuedCommits         int
-	completedBuilds       int
-	currentBuildStartTime *time.Time
-	currentBuildCommitID  int
-	abortedBuilds         int
+// manifestDirMutex returns (creating if necessary) the mutex guarding git
+// operations in dir.
+func manifestDirMutex(dir string) *sync.Mutex {
+	manifestDirMutexesMu.Lock()
+	defer manifestDirMutexesMu.Unlock()
+
+	m, ok := manifestDirMutexes[dir]
+	if !ok {
+		m = &sync.Mutex{}
+		manifestDirMutexes[dir] = m
+	}
+	return m
 }
 
+// CommitEvent describes one commit entering the build queue. sha, author,
+// and message are populated in --commitSource=upstream mode (see
+// upstream.go) and left zero in the default synthetic mode, in which case
+// gitCommitAndPush falls back to a random SHA from commitSHAsCache.
 type CommitEvent struct {
 	timestamp time.Time
 	id        int
+	sha       string
+	author    string
+	message   string
 }
 
 type Kustomization struct {
@@ -75,8 +117,54 @@ Docker image builds, and completed builds result in Kubernetes manifest updates.
 		"Path to the kustomization.yaml file to modify")
 	rootCmd.Flags().BoolVar(&skipGitOperations, "skipGitOperations", false,
 		"If true, skip git commit and push operations")
-
-	rootCmd.MarkFlagRequired("manifestKustomizeFilePath")
+	rootCmd.Flags().StringVar(&httpAddr, "httpAddr", "",
+		"If set, run the status HTTP server on this address (e.g. :8080)")
+	rootCmd.Flags().StringVar(&configFilePath, "config", "",
+		"Path to a multi-service simulation config (see SimConfig). Overrides the single-service flags above")
+	rootCmd.Flags().IntVar(&maxParallelBuilds, "maxParallelBuilds", defaultMaxParallelBuilds,
+		"Maximum number of builds (across all services) that may run at the same time")
+	rootCmd.Flags().Float64Var(&buildFailureRate, "buildFailureRate", 0,
+		"Probability (0..1) that a build fails instead of succeeding")
+	rootCmd.Flags().StringVar(&buildFailurePattern, "buildFailurePattern", "flaky",
+		"Failure pattern when buildFailureRate > 0: flaky (retryable), bimodal (hard failures), or infra-outage (outage windows fail fast)")
+	rootCmd.Flags().StringVar(&buildDurationJitter, "buildDurationJitter", "",
+		"Random variance applied to build duration, e.g. ±20%")
+	rootCmd.Flags().IntVar(&maxRetries, "maxRetries", 3,
+		"Maximum retries for a transient build failure before it is recorded as failed")
+	rootCmd.Flags().StringVar(&commitSource, "commitSource", "synthetic",
+		"Where commits come from: synthetic (pattern generators) or upstream (poll a real git remote)")
+	rootCmd.Flags().StringVar(&upstreamRepo, "upstreamRepo", "",
+		"Git remote URL to poll when --commitSource=upstream")
+	rootCmd.Flags().StringVar(&upstreamBranch, "upstreamBranch", "main",
+		"Branch to poll when --commitSource=upstream")
+	rootCmd.Flags().DurationVar(&pollInterval, "pollInterval", 30*time.Second,
+		"How often to poll the upstream remote when --commitSource=upstream")
+	rootCmd.Flags().StringVar(&cacheDir, "cacheDir", ".promoter-demo-cache",
+		"Directory holding the bare clone and last-seen SHA when --commitSource=upstream")
+	rootCmd.Flags().StringVar(&stateFile, "stateFile", "",
+		"If set, checkpoint stats/queues/in-flight builds to this file on every state transition and resume from it on startup")
+	rootCmd.Flags().Int64Var(&seed, "seed", 0,
+		"Seed math/rand for reproducible runs (default: unseeded)")
+	rootCmd.Flags().StringVar(&replayLog, "replayLog", "",
+		"If set, append a JSONL trace of every commit/build/manifest event to this file")
+	rootCmd.Flags().StringVar(&replayFrom, "replayFrom", "",
+		"Replay commit arrivals from a --replayLog trace captured by a previous run, instead of generating/polling new ones")
+	rootCmd.Flags().Float64Var(&replaySpeed, "replaySpeed", 1,
+		"Playback speed multiplier for --replayFrom (e.g. 10 for 10x wall-clock speed)")
+	rootCmd.Flags().StringVar(&eventSinkKind, "eventSink", "",
+		"Publish simulation events to an external system: webhook, nats, or amqp (default: none)")
+	rootCmd.Flags().StringVar(&webhookURL, "webhookURL", "",
+		"URL to POST JSON events to when --eventSink=webhook")
+	rootCmd.Flags().StringVar(&webhookSecret, "webhookSecret", "",
+		"If set, sign --eventSink=webhook POST bodies with HMAC-SHA256 using this secret")
+	rootCmd.Flags().StringVar(&natsURL, "natsURL", "",
+		"NATS server URL when --eventSink=nats")
+	rootCmd.Flags().StringVar(&natsSubject, "natsSubject", "promoter.demo.events",
+		"Subject to publish to when --eventSink=nats")
+	rootCmd.Flags().StringVar(&amqpURL, "amqpURL", "",
+		"AMQP (RabbitMQ) server URL when --eventSink=amqp")
+	rootCmd.Flags().StringVar(&amqpExchange, "amqpExchange", "promoter.demo.events",
+		"Fanout exchange to publish to when --eventSink=amqp")
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -85,61 +173,125 @@ Docker image builds, and completed builds result in Kubernetes manifest updates.
 }
 
 func runSimulation(cmd *cobra.Command, args []string) error {
-	// Parse build duration
-	buildDuration, err := time.ParseDuration(simulatedBuildDuration)
-	if err != nil {
-		return fmt.Errorf("invalid simulatedBuildDuration: %w", err)
+	if cmd.Flags().Changed("seed") {
+		rand.Seed(seed)
 	}
 
-	// Validate manifest file exists
-	if _, err := os.Stat(manifestKustomizeFilePath); os.IsNotExist(err) {
-		return fmt.Errorf("manifest file does not exist: %s", manifestKustomizeFilePath)
+	if err := openReplayLog(replayLog); err != nil {
+		return err
 	}
 
-	// Fetch commit SHAs from GitHub
-	fmt.Println("🔍 Fetching commit SHAs from gitops-promoter repository...")
-	if err := fetchCommitSHAs(); err != nil {
-		fmt.Printf("⚠️  Warning: Could not fetch commits from GitHub: %v\n", err)
-		fmt.Println("   Using fallback commit SHA")
-		commitSHAsCache = []string{"9d5ccef278218dea4caa903bb6abb9ed974a1d90"}
+	var checkpoint *SimulationCheckpoint
+	if stateFile != "" {
+		loaded, err := loadCheckpoint(stateFile)
+		if err != nil {
+			return err
+		}
+		checkpoint = loaded
+		if checkpoint != nil {
+			fmt.Printf("♻️  Resuming from checkpoint %s (saved %s)\n", stateFile, checkpoint.SavedAt.Format(time.RFC3339))
+		}
+	}
+
+	var cfg *SimConfig
+	if configFilePath != "" {
+		loaded, err := loadSimConfig(configFilePath)
+		if err != nil {
+			return fmt.Errorf("invalid --config: %w", err)
+		}
+		cfg = loaded
 	} else {
-		fmt.Printf("✅ Loaded %d commit SHAs from repository\n", len(commitSHAsCache))
+		if manifestKustomizeFilePath == "" {
+			return fmt.Errorf("either --config or --manifestKustomizeFilePath must be set")
+		}
+		if _, err := os.Stat(manifestKustomizeFilePath); os.IsNotExist(err) {
+			return fmt.Errorf("manifest file does not exist: %s", manifestKustomizeFilePath)
+		}
+		cfg = singleServiceConfig()
+	}
+
+	// Fetch commit SHAs from GitHub, used to populate commit trailers in
+	// synthetic mode. Skipped in --commitSource=upstream mode, where trailers
+	// use the real upstream SHA and commitSHAsCache is never consulted.
+	if commitSource != "upstream" {
+		fmt.Println("🔍 Fetching commit SHAs from gitops-promoter repository...")
+		if err := fetchCommitSHAs(); err != nil {
+			fmt.Printf("⚠️  Warning: Could not fetch commits from GitHub: %v\n", err)
+			fmt.Println("   Using fallback commit SHA")
+			commitSHAsCache = []string{"9d5ccef278218dea4caa903bb6abb9ed974a1d90"}
+		} else {
+			fmt.Printf("✅ Loaded %d commit SHAs from repository\n", len(commitSHAsCache))
+		}
 	}
 
-	stats := &SimulationStats{}
-	commitQueue := make(chan CommitEvent, 100)
-	buildControl := make(chan bool, 1)
+	serviceNames := make([]string, len(cfg.Services))
+	for i, svc := range cfg.Services {
+		serviceNames[i] = svc.Name
+	}
+	stats := NewAggregate(serviceNames)
+	semaphore := make(chan struct{}, cfg.MaxParallelBuilds)
 	done := make(chan bool)
 
+	failureModel, err := newFailureModel(buildFailureRate, buildFailurePattern, buildDurationJitter, maxRetries)
+	if err != nil {
+		return fmt.Errorf("invalid failure model flags: %w", err)
+	}
+
+	if commitSource == "upstream" && upstreamRepo == "" {
+		return fmt.Errorf("--upstreamRepo is required when --commitSource=upstream")
+	}
+
+	sink, err := newEventSink(eventSinkKind, webhookURL, webhookSecret, natsURL, natsSubject, amqpURL, amqpExchange)
+	if err != nil {
+		return fmt.Errorf("invalid --eventSink flags: %w", err)
+	}
+	eventSinkBuffer = sink
+
 	fmt.Println("🚀 Starting CI/CD Pipeline Simulation")
 	fmt.Println("=====================================")
-	fmt.Printf("Build Duration: %v\n", buildDuration)
-	fmt.Printf("Abort on New Commit: %v\n", abortOnNewCommit)
-	fmt.Printf("Commit Rate: %s\n", simulatedCommitRate)
-	fmt.Printf("Manifest File: %s\n", manifestKustomizeFilePath)
+	fmt.Printf("Services: %d\n", len(cfg.Services))
+	fmt.Printf("Max Parallel Builds: %d\n", cfg.MaxParallelBuilds)
+	for _, svc := range cfg.Services {
+		fmt.Printf("  - %s: build=%s rate=%s abortOnNewCommit=%v manifest=%s\n",
+			svc.Name, svc.SimulatedBuildDuration, svc.SimulatedCommitRate, svc.AbortOnNewCommit, svc.ManifestKustomizeFilePath)
+	}
 	fmt.Println("=====================================")
 
-	// Start commit generator
-	go generateCommits(simulatedCommitRate, commitQueue, stats)
-
-	// Start build processor
-	go processBuildQueue(buildDuration, commitQueue, buildControl, stats, done)
+	// Start the optional HTTP status server
+	if httpAddr != "" {
+		go startStatusServer(httpAddr, stats)
+	}
 
-	// Monitor and print stats
+	// Monitor and print stats across all services
 	go printStats(stats, done)
 
-	// Send initial commit to start building immediately
 	fmt.Println()
-	fmt.Println("📝 Initial commit detected: #1 (timestamp: " + time.Now().Format("15:04:05") + ")")
-	initialCommit := CommitEvent{
-		timestamp: time.Now(),
-		id:        1,
+	runtimes := make([]*serviceRuntime, len(cfg.Services))
+	for i, svc := range cfg.Services {
+		rt, err := newServiceRuntime(svc, semaphore, failureModel, stats.Service(svc.Name))
+		if err != nil {
+			return err
+		}
+		applyCheckpoint(rt, checkpoint.forService(svc.Name), rt.buildDuration)
+		runtimes[i] = rt
+	}
+
+	// checkpointRuntimes must be set before any runtime's goroutines start, so
+	// the first saveCheckpoint() call they trigger sees every service.
+	checkpointRuntimes = runtimes
+	for i, svc := range cfg.Services {
+		runtimes[i].run(svc.SimulatedCommitRate)
+	}
+
+	if replayFrom != "" {
+		go func() {
+			if err := replayFromLog(runtimes, replayFrom, replaySpeed); err != nil {
+				fmt.Printf("❌ [replay] %v\n", err)
+			}
+		}()
+	} else if commitSource == "upstream" {
+		go pollUpstreamCommits(runtimes, upstreamRepo, upstreamBranch, pollInterval, cacheDir)
 	}
-	stats.mu.Lock()
-	stats.totalCommits++
-	stats.queuedCommits++
-	stats.mu.Unlock()
-	commitQueue <- initialCommit
 
 	// Wait for interrupt
 	select {}
@@ -207,34 +359,34 @@ func getRandomCommitSHA() string {
 	return commitSHAsCache[rand.Intn(len(commitSHAsCache))]
 }
 
-func generateCommits(rateSpec string, commitQueue chan<- CommitEvent, stats *SimulationStats) {
-	commitID := 2 // Start from 2 since initial commit is 1
+func generateCommits(rateSpec string, rt *serviceRuntime) {
+	commitID := rt.nextCommitID // Normally 2 (initial commit is 1), or resumed from a checkpoint
 
 	// Check for pre-canned patterns
 	pattern := strings.ToLower(rateSpec)
 	switch pattern {
 	case "developer":
-		generateDeveloperPattern(commitQueue, stats, &commitID)
+		generateDeveloperPattern(rt, &commitID)
 	case "burst":
-		generateBurstPattern(commitQueue, stats, &commitID)
+		generateBurstPattern(rt, &commitID)
 	case "steady":
-		generateSteadyPattern(commitQueue, stats, &commitID)
+		generateSteadyPattern(rt, &commitID)
 	case "sporadic":
-		generateSporadicPattern(commitQueue, stats, &commitID)
+		generateSporadicPattern(rt, &commitID)
 	case "rapid":
-		generateRapidPattern(commitQueue, stats, &commitID)
+		generateRapidPattern(rt, &commitID)
 	default:
 		// Use custom duration-based pattern
-		generateCustomPattern(rateSpec, commitQueue, stats, &commitID)
+		generateCustomPattern(rateSpec, rt, &commitID)
 	}
 }
 
 // Developer pattern: Bursts of commits (3-7 commits in quick succession) followed by longer pauses
-func generateDeveloperPattern(commitQueue chan<- CommitEvent, stats *SimulationStats, commitID *int) {
+func generateDeveloperPattern(rt *serviceRuntime, commitID *int) {
 	for {
 		// Burst: 3-7 commits
 		burstSize := 3 + rand.Intn(5)
-		fmt.Printf("💥 Developer burst: %d commits incoming\n", burstSize)
+		fmt.Printf("💥 [%s] Developer burst: %d commits incoming\n", rt.name, burstSize)
 
 		for i := 0; i < burstSize; i++ {
 			if i > 0 {
@@ -242,18 +394,18 @@ func generateDeveloperPattern(commitQueue chan<- CommitEvent, stats *SimulationS
 				time.Sleep(time.Duration(30+rand.Intn(90)) * time.Second)
 			}
 
-			sendCommit(commitQueue, stats, commitID)
+			sendCommit(rt, commitID)
 		}
 
 		// Long pause between bursts (15-45 minutes)
 		pauseDuration := time.Duration(15+rand.Intn(31)) * time.Minute
-		fmt.Printf("😴 Developer taking a break for %v\n", pauseDuration.Round(time.Second))
+		fmt.Printf("😴 [%s] Developer taking a break for %v\n", rt.name, pauseDuration.Round(time.Second))
 		time.Sleep(pauseDuration)
 	}
 }
 
 // Burst pattern: Frequent short bursts with medium pauses
-func generateBurstPattern(commitQueue chan<- CommitEvent, stats *SimulationStats, commitID *int) {
+func generateBurstPattern(rt *serviceRuntime, commitID *int) {
 	for {
 		// Small burst: 2-4 commits
 		burstSize := 2 + rand.Intn(3)
@@ -262,7 +414,7 @@ func generateBurstPattern(commitQueue chan<- CommitEvent, stats *SimulationStats
 			if i > 0 {
 				time.Sleep(time.Duration(20+rand.Intn(40)) * time.Second)
 			}
-			sendCommit(commitQueue, stats, commitID)
+			sendCommit(rt, commitID)
 		}
 
 		// Medium pause (5-10 minutes)
@@ -271,31 +423,31 @@ func generateBurstPattern(commitQueue chan<- CommitEvent, stats *SimulationStats
 }
 
 // Steady pattern: Consistent commits every 2-5 minutes
-func generateSteadyPattern(commitQueue chan<- CommitEvent, stats *SimulationStats, commitID *int) {
+func generateSteadyPattern(rt *serviceRuntime, commitID *int) {
 	for {
 		time.Sleep(time.Duration(2+rand.Intn(4)) * time.Minute)
-		sendCommit(commitQueue, stats, commitID)
+		sendCommit(rt, commitID)
 	}
 }
 
 // Sporadic pattern: Random commits with wide variance (1-30 minutes)
-func generateSporadicPattern(commitQueue chan<- CommitEvent, stats *SimulationStats, commitID *int) {
+func generateSporadicPattern(rt *serviceRuntime, commitID *int) {
 	for {
 		time.Sleep(time.Duration(1+rand.Intn(30)) * time.Minute)
-		sendCommit(commitQueue, stats, commitID)
+		sendCommit(rt, commitID)
 	}
 }
 
 // Rapid pattern: High frequency commits (30s - 2min)
-func generateRapidPattern(commitQueue chan<- CommitEvent, stats *SimulationStats, commitID *int) {
+func generateRapidPattern(rt *serviceRuntime, commitID *int) {
 	for {
 		time.Sleep(time.Duration(30+rand.Intn(90)) * time.Second)
-		sendCommit(commitQueue, stats, commitID)
+		sendCommit(rt, commitID)
 	}
 }
 
 // Custom pattern: Original duration-based logic
-func generateCustomPattern(rateSpec string, commitQueue chan<- CommitEvent, stats *SimulationStats, commitID *int) {
+func generateCustomPattern(rateSpec string, rt *serviceRuntime, commitID *int) {
 	for {
 		var waitDuration time.Duration
 
@@ -304,13 +456,13 @@ func generateCustomPattern(rateSpec string, commitQueue chan<- CommitEvent, stat
 			// Random range: "1m-5m"
 			parts := strings.Split(rateSpec, "-")
 			if len(parts) != 2 {
-				fmt.Printf("⚠️  Invalid commit rate format: %s\n", rateSpec)
+				fmt.Printf("⚠️  [%s] Invalid commit rate format: %s\n", rt.name, rateSpec)
 				waitDuration = 1 * time.Minute
 			} else {
 				minDur, err1 := time.ParseDuration(parts[0])
 				maxDur, err2 := time.ParseDuration(parts[1])
 				if err1 != nil || err2 != nil {
-					fmt.Printf("⚠️  Invalid commit rate format: %s\n", rateSpec)
+					fmt.Printf("⚠️  [%s] Invalid commit rate format: %s\n", rt.name, rateSpec)
 					waitDuration = 1 * time.Minute
 				} else {
 					randomRange := maxDur - minDur
@@ -322,102 +474,189 @@ func generateCustomPattern(rateSpec string, commitQueue chan<- CommitEvent, stat
 			var err error
 			waitDuration, err = time.ParseDuration(rateSpec)
 			if err != nil {
-				fmt.Printf("⚠️  Invalid commit rate format: %s\n", rateSpec)
+				fmt.Printf("⚠️  [%s] Invalid commit rate format: %s\n", rt.name, rateSpec)
 				waitDuration = 1 * time.Minute
 			}
 		}
 
 		time.Sleep(waitDuration)
-		sendCommit(commitQueue, stats, commitID)
+		sendCommit(rt, commitID)
 	}
 }
 
 // Helper function to send a commit
-func sendCommit(commitQueue chan<- CommitEvent, stats *SimulationStats, commitID *int) {
+func sendCommit(rt *serviceRuntime, commitID *int) {
 	commit := CommitEvent{
 		timestamp: time.Now(),
 		id:        *commitID,
 	}
 
-	stats.mu.Lock()
-	stats.totalCommits++
-	stats.queuedCommits++
-	stats.mu.Unlock()
+	rt.stats.IncCommits()
+	rt.stats.IncQueueDepth(1)
 
-	fmt.Printf("📝 New commit detected: #%d (timestamp: %s)\n",
-		commit.id, commit.timestamp.Format("15:04:05"))
+	fmt.Printf("📝 [%s] New commit detected: #%d (timestamp: %s)\n",
+		rt.name, commit.id, commit.timestamp.Format("15:04:05"))
+
+	rt.commitQueue <- commit
+	rt.enqueueSnapshot(commit)
+	logReplayEvent(replayEvent{Type: "commit", Service: rt.name, CommitID: commit.id})
+	emitEvent(Event{Type: EventCommit, ServiceName: rt.name, CommitID: commit.id})
 
-	commitQueue <- commit
 	*commitID++
+	rt.setNextCommitID(*commitID)
+	saveCheckpoint()
 }
 
-func processBuildQueue(buildDuration time.Duration, commitQueue <-chan CommitEvent,
-	buildControl chan bool, stats *SimulationStats, done chan bool) {
-
+func processBuildQueue(rt *serviceRuntime) {
 	var currentBuild *CommitEvent
 	var buildTimer *time.Timer
 
+	// buildMu guards currentBuild: it's written by this loop's goroutine and,
+	// via each build's AfterFunc callback, by a timer goroutine running
+	// concurrently with it. Every read or write below goes through
+	// getCurrentBuild/setCurrentBuild rather than touching currentBuild
+	// directly.
+	var buildMu sync.Mutex
+	getCurrentBuild := func() *CommitEvent {
+		buildMu.Lock()
+		defer buildMu.Unlock()
+		return currentBuild
+	}
+	setCurrentBuild := func(c *CommitEvent) {
+		buildMu.Lock()
+		currentBuild = c
+		buildMu.Unlock()
+	}
+
+	// buildSettled guards the currently active build's AfterFunc callback
+	// against the abort path below: buildTimer.Stop() returning false only
+	// means the timer already fired, not that its callback has finished (or
+	// even started) running, so both sides race to release rt.semaphore.
+	// Each build gets its own *int32 (captured by its AfterFunc closure, not
+	// shared across builds), and whichever side wins the CompareAndSwap is
+	// the one that actually releases the permit.
+	var buildSettled *int32
+
+	// Resume an in-flight build loaded from a --stateFile checkpoint before
+	// falling into the normal commit-consuming loop below.
+	if resume := rt.pendingResume; resume != nil {
+		rt.pendingResume = nil
+		rt.semaphore <- struct{}{}
+
+		commit := resume.Commit
+		setCurrentBuild(&commit)
+		start := resume.OriginalStart
+		rt.setActiveBuild(&commit)
+		settled := new(int32)
+		buildSettled = settled
+
+		fmt.Printf("♻️  [%s] Resuming build for commit #%d (remaining: %v)\n", rt.name, commit.id, resume.Remaining)
+		logReplayEvent(replayEvent{Type: "build_start", Service: rt.name, CommitID: commit.id, SHA: commit.sha})
+		emitEvent(Event{Type: EventBuildStart, ServiceName: rt.name, CommitID: commit.id, SHA: commit.sha})
+
+		buildTimer = time.AfterFunc(resume.Remaining, func() {
+			if !atomic.CompareAndSwapInt32(settled, 0, 1) {
+				return
+			}
+			completeBuild(commit, start, rt)
+			setCurrentBuild(nil)
+			rt.setActiveBuild(nil)
+			rt.stats.ClearCurrentBuild()
+			rt.stats.SetQueueDepth(0)
+			<-rt.semaphore
+			saveCheckpoint()
+		})
+	}
+
 	for {
-		if abortOnNewCommit {
+		if rt.abortOnNewCommit {
 			// Abort mode: always listen for new commits
-			commit := <-commitQueue
+			commit := <-rt.commitQueue
+			rt.dequeueSnapshot()
 
-			if currentBuild != nil {
+			if building := getCurrentBuild(); building != nil {
 				// Abort current build
 				if buildTimer != nil {
 					buildTimer.Stop()
 				}
-				stats.mu.Lock()
-				stats.abortedBuilds++
-				stats.mu.Unlock()
-				fmt.Printf("❌ Build aborted for commit #%d due to new commit #%d\n",
-					currentBuild.id, commit.id)
+				rt.stats.RecordAbort()
+				rt.setActiveBuild(nil)
+				if atomic.CompareAndSwapInt32(buildSettled, 0, 1) {
+					// We won the race against the build's own AfterFunc
+					// callback, so it's on us to release its permit.
+					<-rt.semaphore
+				}
+				fmt.Printf("❌ [%s] Build aborted for commit #%d due to new commit #%d\n",
+					rt.name, building.id, commit.id)
+				logReplayEvent(replayEvent{Type: "build_abort", Service: rt.name, CommitID: building.id})
+				emitEvent(Event{Type: EventBuildAbort, ServiceName: rt.name, CommitID: building.id})
 			}
 
+			// Acquire a build-farm permit before starting the new build; this
+			// is what actually models contention across services.
+			rt.semaphore <- struct{}{}
+
 			// Start new build
-			currentBuild = &commit
+			setCurrentBuild(&commit)
 			now := time.Now()
-			stats.mu.Lock()
-			stats.currentBuildStartTime = &now
-			stats.currentBuildCommitID = commit.id
-			stats.queuedCommits = 1 // Only current one in "queue"
-			stats.mu.Unlock()
-
-			fmt.Printf("🔨 Starting build for commit #%d (duration: %v)\n",
-				commit.id, buildDuration)
+			rt.stats.StartBuild(commit.id, now)
+			rt.stats.SetQueueDepth(1) // Only current one in "queue"
+			rt.setActiveBuild(&commit)
+			settled := new(int32)
+			buildSettled = settled
+
+			buildDuration := rt.failureModel.effectiveDuration(rt.buildDuration)
+			fmt.Printf("🔨 [%s] Starting build for commit #%d (duration: %v)\n",
+				rt.name, commit.id, buildDuration)
+			logReplayEvent(replayEvent{Type: "build_start", Service: rt.name, CommitID: commit.id, SHA: commit.sha})
+			emitEvent(Event{Type: EventBuildStart, ServiceName: rt.name, CommitID: commit.id, SHA: commit.sha})
+			saveCheckpoint()
 
 			buildTimer = time.AfterFunc(buildDuration, func() {
-				completeBuild(*currentBuild, stats)
-				currentBuild = nil
-				stats.mu.Lock()
-				stats.currentBuildStartTime = nil
-				stats.currentBuildCommitID = 0
-				stats.queuedCommits = 0
-				stats.mu.Unlock()
+				if !atomic.CompareAndSwapInt32(settled, 0, 1) {
+					return
+				}
+				completeBuild(commit, now, rt)
+				setCurrentBuild(nil)
+				rt.setActiveBuild(nil)
+				rt.stats.ClearCurrentBuild()
+				rt.stats.SetQueueDepth(0)
+				<-rt.semaphore
+				saveCheckpoint()
 			})
 		} else {
 			// Queue mode: only consume commits when not building
-			if currentBuild == nil {
+			if getCurrentBuild() == nil {
 				// No build in progress, wait for a commit
-				commit := <-commitQueue
-				currentBuild = &commit
-				now := time.Now()
-				stats.mu.Lock()
-				stats.currentBuildStartTime = &now
-				stats.currentBuildCommitID = commit.id
-				stats.queuedCommits--
-				stats.mu.Unlock()
+				commit := <-rt.commitQueue
+				rt.dequeueSnapshot()
+				rt.semaphore <- struct{}{} // acquire a build-farm permit
 
-				fmt.Printf("🔨 Starting build for commit #%d (duration: %v)\n",
-					commit.id, buildDuration)
+				setCurrentBuild(&commit)
+				now := time.Now()
+				rt.stats.StartBuild(commit.id, now)
+				rt.stats.IncQueueDepth(-1)
+				rt.setActiveBuild(&commit)
+				settled := new(int32)
+				buildSettled = settled
+
+				buildDuration := rt.failureModel.effectiveDuration(rt.buildDuration)
+				fmt.Printf("🔨 [%s] Starting build for commit #%d (duration: %v)\n",
+					rt.name, commit.id, buildDuration)
+				logReplayEvent(replayEvent{Type: "build_start", Service: rt.name, CommitID: commit.id, SHA: commit.sha})
+				emitEvent(Event{Type: EventBuildStart, ServiceName: rt.name, CommitID: commit.id, SHA: commit.sha})
+				saveCheckpoint()
 
 				buildTimer = time.AfterFunc(buildDuration, func() {
-					completeBuild(*currentBuild, stats)
-					currentBuild = nil
-					stats.mu.Lock()
-					stats.currentBuildStartTime = nil
-					stats.currentBuildCommitID = 0
-					stats.mu.Unlock()
+					if !atomic.CompareAndSwapInt32(settled, 0, 1) {
+						return
+					}
+					completeBuild(commit, now, rt)
+					setCurrentBuild(nil)
+					rt.setActiveBuild(nil)
+					rt.stats.ClearCurrentBuild()
+					<-rt.semaphore
+					saveCheckpoint()
 				})
 			} else {
 				// Build in progress, just wait a bit
@@ -427,31 +666,51 @@ func processBuildQueue(buildDuration time.Duration, commitQueue <-chan CommitEve
 	}
 }
 
-func completeBuild(commit CommitEvent, stats *SimulationStats) {
-	fmt.Printf("✅ Build completed for commit #%d\n", commit.id)
+func completeBuild(commit CommitEvent, buildStart time.Time, rt *serviceRuntime) {
+	outcome, attempts := rt.failureModel.resolve(rt.stats)
+	status := outcome.trailerStatus()
 
-	// Update kustomization file
-	if err := bumpManifestVersion(); err != nil {
-		fmt.Printf("❌ Error updating manifest: %v\n", err)
+	if outcome == outcomeHardFailure {
+		rt.stats.RecordFailure()
+		fmt.Printf("🔥 [%s] Build failed for commit #%d after %d attempt(s)\n", rt.name, commit.id, attempts)
 	} else {
-		stats.mu.Lock()
-		stats.completedBuilds++
-		stats.mu.Unlock()
-		fmt.Printf("📦 Manifest updated and committed for build #%d\n", commit.id)
+		rt.stats.RecordCompletion(commit.id, time.Since(buildStart))
+		fmt.Printf("✅ [%s] Build completed for commit #%d (status: %s, attempts: %d)\n", rt.name, commit.id, status, attempts)
+	}
+	logReplayEvent(replayEvent{Type: "build_complete", Service: rt.name, CommitID: commit.id, SHA: commit.sha, Status: status})
+	emitEvent(Event{Type: EventBuildComplete, ServiceName: rt.name, CommitID: commit.id, SHA: commit.sha})
+
+	// Update kustomization file, recording the build outcome in the commit trailer
+	if newVersion, err := bumpManifestVersion(rt.manifestPath, status, commit); err != nil {
+		fmt.Printf("❌ [%s] Error updating manifest: %v\n", rt.name, err)
+	} else {
+		fmt.Printf("📦 [%s] Manifest updated and committed for build #%d\n", rt.name, commit.id)
+		logReplayEvent(replayEvent{Type: "manifest_push", Service: rt.name, CommitID: commit.id, Version: newVersion})
+		emitEvent(Event{Type: EventManifestPush, ServiceName: rt.name, CommitID: commit.id, Version: newVersion})
 	}
 }
 
-func bumpManifestVersion() error {
+func bumpManifestVersion(manifestPath string, status string, commit CommitEvent) (string, error) {
+	// Multiple services may point at the same manifest directory (e.g. a
+	// mono-repo); serialize git operations per directory so they don't race.
+	absPath, err := filepath.Abs(manifestPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	dirMutex := manifestDirMutex(filepath.Dir(absPath))
+	dirMutex.Lock()
+	defer dirMutex.Unlock()
+
 	// Read the kustomization file
-	data, err := os.ReadFile(manifestKustomizeFilePath)
+	data, err := os.ReadFile(manifestPath)
 	if err != nil {
-		return fmt.Errorf("failed to read manifest: %w", err)
+		return "", fmt.Errorf("failed to read manifest: %w", err)
 	}
 
 	// Parse YAML
 	var kust Kustomization
 	if err := yaml.Unmarshal(data, &kust); err != nil {
-		return fmt.Errorf("failed to parse manifest: %w", err)
+		return "", fmt.Errorf("failed to parse manifest: %w", err)
 	}
 
 	// Get current version
@@ -462,7 +721,7 @@ func bumpManifestVersion() error {
 	matches := re.FindAllString(currentVersion, -1)
 
 	if len(matches) == 0 {
-		return fmt.Errorf("no version number found in: %s", currentVersion)
+		return "", fmt.Errorf("no version number found in: %s", currentVersion)
 	}
 
 	lastNumStr := matches[len(matches)-1]
@@ -482,17 +741,17 @@ func bumpManifestVersion() error {
 	// Marshal back to YAML
 	updatedData, err := yaml.Marshal(&kust)
 	if err != nil {
-		return fmt.Errorf("failed to marshal manifest: %w", err)
+		return "", fmt.Errorf("failed to marshal manifest: %w", err)
 	}
 
 	// Write back to file
-	if err := os.WriteFile(manifestKustomizeFilePath, updatedData, 0644); err != nil {
-		return fmt.Errorf("failed to write manifest: %w", err)
+	if err := os.WriteFile(manifestPath, updatedData, 0644); err != nil {
+		return "", fmt.Errorf("failed to write manifest: %w", err)
 	}
 
 	// Git commit and push
 	if !skipGitOperations {
-		if err := gitCommitAndPush(newVersion); err != nil {
+		if err := gitCommitAndPush(manifestPath, newVersion, status, commit); err != nil {
 			fmt.Printf("⚠️  Git operations failed: %v\n", err)
 			// Don't return error - we still updated the file
 		}
@@ -500,12 +759,12 @@ func bumpManifestVersion() error {
 		fmt.Printf("⚠️  Git operations skipped (--skipGitOperations=true)\n")
 	}
 
-	return nil
+	return newVersion, nil
 }
 
-func gitCommitAndPush(version string) error {
+func gitCommitAndPush(manifestPath string, version string, status string, commit CommitEvent) error {
 	// Get absolute path and directory
-	absPath, err := filepath.Abs(manifestKustomizeFilePath)
+	absPath, err := filepath.Abs(manifestPath)
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
@@ -522,32 +781,45 @@ func gitCommitAndPush(version string) error {
 		return fmt.Errorf("git add failed: %w (stderr: %s)", err, stderr.String())
 	}
 
-	// Generate a random date older than 5 days
-	daysAgo := 5 + rand.Intn(30) // 5-35 days ago
-	hoursAgo := rand.Intn(24)
-	minutesAgo := rand.Intn(60)
-	secondsAgo := rand.Intn(60)
-
-	randomPastDate := time.Now().AddDate(0, 0, -daysAgo).
-		Add(-time.Duration(hoursAgo) * time.Hour).
-		Add(-time.Duration(minutesAgo) * time.Minute).
-		Add(-time.Duration(secondsAgo) * time.Second)
+	// In --commitSource=upstream mode the trailers describe the real commit
+	// that triggered this build; otherwise fall back to the synthetic
+	// random-SHA/random-past-date behavior.
+	var trailerSHA, trailerAuthor, trailerSubject, trailerBody, trailerDate string
+	if commit.sha != "" {
+		trailerSHA = commit.sha
+		trailerAuthor = commit.author
+		trailerSubject = commit.message
+		trailerBody = fmt.Sprintf("%q", commit.message)
+		trailerDate = commit.timestamp.Format("2006-01-02T15:04:05-07:00")
+	} else {
+		daysAgo := 5 + rand.Intn(30) // 5-35 days ago
+		hoursAgo := rand.Intn(24)
+		minutesAgo := rand.Intn(60)
+		secondsAgo := rand.Intn(60)
 
-	formattedDate := randomPastDate.Format("2006-01-02T15:04:05-07:00")
+		randomPastDate := time.Now().AddDate(0, 0, -daysAgo).
+			Add(-time.Duration(hoursAgo) * time.Hour).
+			Add(-time.Duration(minutesAgo) * time.Minute).
+			Add(-time.Duration(secondsAgo) * time.Second)
 
-	// Get a random commit SHA from the cache
-	randomSHA := getRandomCommitSHA()
+		trailerSHA = getRandomCommitSHA()
+		trailerAuthor = "Zach Aller <code@example.com>"
+		trailerSubject = fmt.Sprintf("This change fixes a bug in the code %s", version)
+		trailerBody = `"Commit message of the code commit\n\nSigned-off-by: Author Name <author@example.com>"`
+		trailerDate = randomPastDate.Format("2006-01-02T15:04:05-07:00")
+	}
 
 	// Git commit with trailers
 	commitMsg := fmt.Sprintf(`chore: bump version to %s
 
-Argocd-reference-commit-author: Zach Aller <code@example.com>
+Argocd-reference-commit-author: %s
 Argocd-reference-commit-sha: %s
-Argocd-reference-commit-subject: This change fixes a bug in the code %s
-Argocd-reference-commit-body: "Commit message of the code commit\n\nSigned-off-by: Author Name <author@example.com>"
+Argocd-reference-commit-subject: %s
+Argocd-reference-commit-body: %s
 Argocd-reference-commit-repourl: https://github.com/argoproj-labs/gitops-promoter
 Argocd-reference-commit-date: %s
-Signed-off-by: Zach Aller <zach_aller@intuit.com>`, version, randomSHA, version, formattedDate)
+Argocd-reference-commit-build-status: %s
+Signed-off-by: Zach Aller <zach_aller@intuit.com>`, version, trailerAuthor, trailerSHA, trailerSubject, trailerBody, trailerDate, status)
 
 	cmd = exec.Command("git", "commit", "-m", commitMsg)
 	cmd.Dir = dir
@@ -569,30 +841,33 @@ Signed-off-by: Zach Aller <zach_aller@intuit.com>`, version, randomSHA, version,
 	return nil
 }
 
-func printStats(stats *SimulationStats, done chan bool) {
+func printStats(stats *Aggregate, done chan bool) {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			stats.mu.Lock()
+			agg := stats.Snapshot()
 			fmt.Println("\n📊 === Statistics ===")
-			fmt.Printf("Total Commits: %d\n", stats.totalCommits)
-			fmt.Printf("Queued Commits: %d\n", stats.queuedCommits)
-			fmt.Printf("Completed Builds: %d\n", stats.completedBuilds)
-			if abortOnNewCommit {
-				fmt.Printf("Aborted Builds: %d\n", stats.abortedBuilds)
-			}
-
-			if stats.currentBuildStartTime != nil {
-				elapsed := time.Since(*stats.currentBuildStartTime)
-				fmt.Printf("Current Build: Commit #%d (%v elapsed)\n", stats.currentBuildCommitID, elapsed.Round(time.Second))
-			} else {
-				fmt.Println("Current Build: None")
+			fmt.Printf("Total Commits: %d\n", agg.Total.TotalCommits)
+			fmt.Printf("Queued Commits: %d\n", agg.Total.QueuedCommits)
+			fmt.Printf("Completed Builds: %d\n", agg.Total.CompletedBuilds)
+			fmt.Printf("Aborted Builds: %d\n", agg.Total.AbortedBuilds)
+			fmt.Printf("Failed Builds: %d\n", agg.Total.FailedBuilds)
+			fmt.Printf("Retries: %d\n", agg.Total.Retries)
+
+			for _, svc := range agg.Services {
+				current := "None"
+				if svc.Snapshot.CurrentBuildStartTime != nil {
+					elapsed := time.Since(*svc.Snapshot.CurrentBuildStartTime)
+					current = fmt.Sprintf("Commit #%d (%v elapsed)", svc.Snapshot.CurrentBuildCommitID, elapsed.Round(time.Second))
+				}
+				fmt.Printf("  [%s] queued=%d completed=%d aborted=%d failed=%d retries=%d current=%s\n",
+					svc.Name, svc.Snapshot.QueuedCommits, svc.Snapshot.CompletedBuilds, svc.Snapshot.AbortedBuilds,
+					svc.Snapshot.FailedBuilds, svc.Snapshot.Retries, current)
 			}
 			fmt.Println("===================")
-			stats.mu.Unlock()
 
 		case <-done:
 			return
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// replayEvent is one line of a --replayLog JSONL trace: a timestamped record
+// of a commit/build/manifest transition, detailed enough for --replayFrom to
+// re-drive the same commit arrivals against a (possibly different) build
+// configuration.
+type replayEvent struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"` // commit, build_start, build_complete, build_abort, manifest_push
+	Service  string    `json:"service"`
+	CommitID int       `json:"commitID,omitempty"`
+	SHA      string    `json:"sha,omitempty"`
+	Author   string    `json:"author,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Status   string    `json:"status,omitempty"`
+	Version  string    `json:"version,omitempty"`
+}
+
+// replayUpstreamService is the Service recorded against commit events
+// discovered by --commitSource=upstream (see pollUpstreamCommits), which
+// fans a single upstream repo's commits out to every configured service
+// rather than belonging to one of them.
+const replayUpstreamService = "upstream"
+
+var (
+	replayLogMu sync.Mutex
+	replayWrite *os.File
+)
+
+// openReplayLog opens (creating/appending to) the --replayLog file. A no-op
+// if path is empty.
+func openReplayLog(path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open --replayLog file: %w", err)
+	}
+	replayWrite = f
+	return nil
+}
+
+// logReplayEvent appends one event to the --replayLog trace, stamping it
+// with the current time. A no-op if --replayLog wasn't set.
+func logReplayEvent(e replayEvent) {
+	if replayWrite == nil {
+		return
+	}
+	e.Time = time.Now()
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	replayLogMu.Lock()
+	defer replayLogMu.Unlock()
+	replayWrite.Write(data)
+}
+
+// replayFromLog re-drives the commit queues of runtimes from a --replayLog
+// trace captured by a previous run, preserving the original inter-commit
+// gaps scaled by speed (speed=1 plays back at wall-clock speed, speed=10
+// ten times faster). Only "commit" events are replayed; build/manifest
+// events in the trace describe what that earlier run's simulator did with
+// each commit, which this run's (possibly different) failure/build
+// configuration is free to redo differently.
+func replayFromLog(runtimes []*serviceRuntime, path string, speed float64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open --replayFrom file: %w", err)
+	}
+	defer f.Close()
+
+	byService := make(map[string]*serviceRuntime, len(runtimes))
+	for _, rt := range runtimes {
+		byService[rt.name] = rt
+	}
+
+	if speed <= 0 {
+		speed = 1
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lastTime time.Time
+	for scanner.Scan() {
+		var e replayEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if e.Type != "commit" {
+			continue
+		}
+
+		if !lastTime.IsZero() {
+			if gap := e.Time.Sub(lastTime); gap > 0 {
+				time.Sleep(time.Duration(float64(gap) / speed))
+			}
+		}
+		lastTime = e.Time
+
+		commit := CommitEvent{timestamp: e.Time, id: e.CommitID, sha: e.SHA, author: e.Author, message: e.Message}
+
+		// A recorded "upstream" event fanned out to every service when it was
+		// captured (see pollUpstreamCommits), so replay it the same way
+		// instead of looking it up as a single service name.
+		targets := runtimes
+		if e.Service != replayUpstreamService {
+			rt, ok := byService[e.Service]
+			if !ok {
+				continue
+			}
+			targets = []*serviceRuntime{rt}
+		}
+
+		for _, rt := range targets {
+			fmt.Printf("🔁 [%s] Replaying commit #%d from %s\n", rt.name, commit.id, path)
+			rt.stats.IncCommits()
+			rt.stats.IncQueueDepth(1)
+			rt.commitQueue <- commit
+			rt.enqueueSnapshot(commit)
+		}
+		saveCheckpoint()
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// startStatusServer spins up the optional HTTP status server exposing
+// health, metrics, profiling, and a small live dashboard. It runs until the
+// process exits, logging (rather than returning) any listen error since the
+// simulation itself should keep running even if the address is unavailable.
+func startStatusServer(addr string, stats *Aggregate) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ok")
+	})
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := stats.WriteMetrics(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		renderDashboard(w, stats.Snapshot())
+	})
+
+	fmt.Printf("🌐 Status server listening on %s (/healthz, /metrics, /debug/pprof, /)\n", addr)
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+	if err := srv.ListenAndServe(); err != nil {
+		fmt.Printf("⚠️  Status server stopped: %v\n", err)
+	}
+}
+
+const dashboardTemplateSource = `<!DOCTYPE html>
+<html>
+<head>
+  <title>promoter-demo-generator</title>
+  <meta http-equiv="refresh" content="5">
+  <style>
+    body { font-family: monospace; margin: 2rem; }
+    table { border-collapse: collapse; }
+    td, th { padding: 0.25rem 0.75rem; text-align: left; }
+    h2 { margin-top: 2rem; }
+  </style>
+</head>
+<body>
+  <h1>🚀 promoter-demo-generator</h1>
+  <h2>Aggregate</h2>
+  <table>
+    <tr><td>Total Commits</td><td>{{.Total.TotalCommits}}</td></tr>
+    <tr><td>Queued Commits</td><td>{{.Total.QueuedCommits}}</td></tr>
+    <tr><td>Completed Builds</td><td>{{.Total.CompletedBuilds}}</td></tr>
+    <tr><td>Aborted Builds</td><td>{{.Total.AbortedBuilds}}</td></tr>
+    <tr><td>Failed Builds</td><td>{{.Total.FailedBuilds}}</td></tr>
+    <tr><td>Retries</td><td>{{.Total.Retries}}</td></tr>
+  </table>
+  <h2>Services</h2>
+  <table>
+    <tr><th>Service</th><th>Queued</th><th>Completed</th><th>Aborted</th><th>Failed</th><th>Current Build</th></tr>
+    {{range .Services}}<tr><td>{{.Name}}</td><td>{{.Queued}}</td><td>{{.Completed}}</td><td>{{.Aborted}}</td><td>{{.Failed}}</td><td>{{.Current}}</td></tr>
+    {{end}}
+  </table>
+  <h2>Recent Builds</h2>
+  <table>
+    <tr><th>Service</th><th>Commit</th><th>Duration</th><th>Finished</th></tr>
+    {{range .RecentBuilds}}<tr><td>{{.Service}}</td><td>#{{.CommitID}}</td><td>{{.Duration}}</td><td>{{.Finished}}</td></tr>
+    {{end}}
+  </table>
+</body>
+</html>
+`
+
+// dashboardTemplate is parsed once at package init; html/template escapes
+// every field it substitutes, so a service name from an untrusted --config
+// can't break the page or inject markup.
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(dashboardTemplateSource))
+
+// dashboardService is one row of the Services table.
+type dashboardService struct {
+	Name                               string
+	Queued, Completed, Aborted, Failed int
+	Current                            string
+}
+
+// dashboardBuild is one row of the Recent Builds table.
+type dashboardBuild struct {
+	Service  string
+	CommitID int
+	Duration string
+	Finished string
+}
+
+// dashboardData is the data renderDashboard feeds to dashboardTemplate.
+type dashboardData struct {
+	Total        Snapshot
+	Services     []dashboardService
+	RecentBuilds []dashboardBuild
+}
+
+func renderDashboard(w http.ResponseWriter, agg AggregateSnapshot) {
+	data := dashboardData{Total: agg.Total}
+	for _, svc := range agg.Services {
+		current := "None"
+		if svc.Snapshot.CurrentBuildStartTime != nil {
+			current = fmt.Sprintf("#%d (%v elapsed)", svc.Snapshot.CurrentBuildCommitID,
+				time.Since(*svc.Snapshot.CurrentBuildStartTime).Round(time.Second))
+		}
+		data.Services = append(data.Services, dashboardService{
+			Name:      svc.Name,
+			Queued:    svc.Snapshot.QueuedCommits,
+			Completed: svc.Snapshot.CompletedBuilds,
+			Aborted:   svc.Snapshot.AbortedBuilds,
+			Failed:    svc.Snapshot.FailedBuilds,
+			Current:   current,
+		})
+
+		for i := len(svc.Snapshot.RecentBuilds) - 1; i >= 0; i-- {
+			b := svc.Snapshot.RecentBuilds[i]
+			data.RecentBuilds = append(data.RecentBuilds, dashboardBuild{
+				Service:  svc.Name,
+				CommitID: b.CommitID,
+				Duration: b.Duration.Round(time.Second).String(),
+				Finished: b.Finished.Format("15:04:05"),
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRenderDashboardEscapesServiceNames is a regression test: a --config
+// service name containing HTML metacharacters must not be able to inject
+// markup into the dashboard page.
+func TestRenderDashboardEscapesServiceNames(t *testing.T) {
+	agg := AggregateSnapshot{
+		Services: []ServiceSnapshot{
+			{Name: `<script>alert(1)</script>`, Snapshot: Snapshot{}},
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	renderDashboard(rec, agg)
+
+	body := rec.Body.String()
+	if strings.Contains(body, "<script>alert(1)</script>") {
+		t.Fatalf("dashboard body contains unescaped service name markup:\n%s", body)
+	}
+	if !strings.Contains(body, "&lt;script&gt;") {
+		t.Errorf("expected the service name to appear HTML-escaped, got:\n%s", body)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// resumedBuild captures an in-flight build loaded from a --stateFile
+// checkpoint, so processBuildQueue can resume it with its remaining
+// duration instead of starting a fresh one.
+type resumedBuild struct {
+	Commit        CommitEvent
+	OriginalStart time.Time
+	Remaining     time.Duration
+}
+
+// serviceRuntime bundles everything one simulated service's goroutines need:
+// its commit queue, build parameters, the shared build semaphore, and the
+// Registry it reports into. Grouping these here means a growing set of
+// per-service concerns can hang off this struct instead of widening every
+// function's parameter list.
+type serviceRuntime struct {
+	name             string
+	manifestPath     string
+	buildDuration    time.Duration
+	abortOnNewCommit bool
+
+	commitQueue  chan CommitEvent
+	buildControl chan bool
+	semaphore    chan struct{}
+
+	failureModel *FailureModel
+	stats        *Registry
+
+	// resumable state mirrors what's in flight on commitQueue/processBuildQueue
+	// purely so a --stateFile checkpoint can be written without draining the
+	// channel. Guarded by mu rather than stats.mu since it tracks queue/build
+	// contents, not counters.
+	mu           sync.Mutex
+	queuedSnap   []CommitEvent
+	activeBuild  *CommitEvent
+	nextCommitID int
+
+	// resumed is true if this runtime was rehydrated from a checkpoint, so
+	// run() should skip synthesizing a fresh "initial commit #1".
+	resumed bool
+	// pendingResume, if set by applyCheckpoint, is consumed once by
+	// processBuildQueue on startup to resume an in-flight build.
+	pendingResume *resumedBuild
+}
+
+// newServiceRuntime builds the runtime for one ServiceConfig entry. semaphore
+// and failureModel are shared across all services in the simulation: the
+// semaphore models a build farm with a fixed number of runners, and
+// failureModel's infra-outage state is a simulation-wide event.
+func newServiceRuntime(svc ServiceConfig, semaphore chan struct{}, failureModel *FailureModel, stats *Registry) (*serviceRuntime, error) {
+	buildDuration, err := time.ParseDuration(svc.SimulatedBuildDuration)
+	if err != nil {
+		return nil, fmt.Errorf("service %q: invalid simulatedBuildDuration: %w", svc.Name, err)
+	}
+
+	return &serviceRuntime{
+		name:             svc.Name,
+		manifestPath:     svc.ManifestKustomizeFilePath,
+		buildDuration:    buildDuration,
+		abortOnNewCommit: svc.AbortOnNewCommit,
+		commitQueue:      make(chan CommitEvent, 100),
+		buildControl:     make(chan bool, 1),
+		semaphore:        semaphore,
+		failureModel:     failureModel,
+		stats:            stats,
+		nextCommitID:     2, // 1 is reserved for the synthesized initial commit
+	}, nil
+}
+
+// enqueueSnapshot records a commit that was just pushed onto commitQueue, so
+// it's included in the next --stateFile checkpoint.
+func (rt *serviceRuntime) enqueueSnapshot(c CommitEvent) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.queuedSnap = append(rt.queuedSnap, c)
+}
+
+// dequeueSnapshot drops the oldest recorded commit, mirroring a receive from
+// commitQueue.
+func (rt *serviceRuntime) dequeueSnapshot() {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if len(rt.queuedSnap) > 0 {
+		rt.queuedSnap = rt.queuedSnap[1:]
+	}
+}
+
+// setActiveBuild records (or clears, if c is nil) the commit currently being
+// built, for --stateFile checkpointing.
+func (rt *serviceRuntime) setActiveBuild(c *CommitEvent) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.activeBuild = c
+}
+
+// setNextCommitID records the next synthetic commit ID this service will
+// assign, so a resumed run doesn't repeat IDs already used.
+func (rt *serviceRuntime) setNextCommitID(id int) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.nextCommitID = id
+}
+
+// checkpointState returns a lock-free copy of the resumable state tracked
+// above, for buildCheckpoint to serialize.
+func (rt *serviceRuntime) checkpointState() ([]CommitEvent, *CommitEvent, int) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	queued := append([]CommitEvent(nil), rt.queuedSnap...)
+	var active *CommitEvent
+	if rt.activeBuild != nil {
+		c := *rt.activeBuild
+		active = &c
+	}
+	return queued, active, rt.nextCommitID
+}
+
+// run starts the build processor for this service. In --commitSource=synthetic
+// mode (the default) it also starts the pattern-based commit generator and
+// seeds an initial commit, mirroring the single-service bootstrap; in
+// --commitSource=upstream mode, or when --replayFrom drives commits from a
+// captured log, commits instead arrive from that shared goroutine started in
+// runSimulation. A runtime rehydrated from a --stateFile checkpoint skips the
+// synthesized initial commit since its queue/build state was already restored.
+func (rt *serviceRuntime) run(commitRate string) {
+	go processBuildQueue(rt)
+
+	if commitSource == "upstream" || replayFrom != "" {
+		return
+	}
+
+	go generateCommits(commitRate, rt)
+
+	if rt.resumed {
+		return
+	}
+
+	commit := CommitEvent{timestamp: time.Now(), id: 1}
+	fmt.Printf("📝 [%s] Initial commit detected: #1 (timestamp: %s)\n", rt.name, commit.timestamp.Format("15:04:05"))
+	rt.stats.IncCommits()
+	rt.stats.IncQueueDepth(1)
+	rt.commitQueue <- commit
+	rt.enqueueSnapshot(commit)
+	logReplayEvent(replayEvent{Type: "commit", Service: rt.name, CommitID: commit.id})
+	emitEvent(Event{Type: EventCommit, ServiceName: rt.name, CommitID: commit.id})
+	saveCheckpoint()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestProcessBuildQueueSharedSemaphoreSerializesBuilds verifies that two
+// services sharing a single-slot build-farm semaphore (as --config's shared
+// semaphore models) run their builds one at a time rather than concurrently.
+func TestProcessBuildQueueSharedSemaphoreSerializesBuilds(t *testing.T) {
+	fm, err := newFailureModel(0, "", "", 3)
+	if err != nil {
+		t.Fatalf("newFailureModel: %v", err)
+	}
+
+	semaphore := make(chan struct{}, 1)
+	const buildDuration = 150 * time.Millisecond
+
+	rt1, err := newServiceRuntime(ServiceConfig{
+		Name:                      "svc1",
+		ManifestKustomizeFilePath: "unused.yaml",
+		SimulatedBuildDuration:    buildDuration.String(),
+		SimulatedCommitRate:       "1m",
+	}, semaphore, fm, NewRegistry())
+	if err != nil {
+		t.Fatalf("newServiceRuntime(svc1): %v", err)
+	}
+	rt2, err := newServiceRuntime(ServiceConfig{
+		Name:                      "svc2",
+		ManifestKustomizeFilePath: "unused.yaml",
+		SimulatedBuildDuration:    buildDuration.String(),
+		SimulatedCommitRate:       "1m",
+	}, semaphore, fm, NewRegistry())
+	if err != nil {
+		t.Fatalf("newServiceRuntime(svc2): %v", err)
+	}
+
+	go processBuildQueue(rt1)
+	go processBuildQueue(rt2)
+
+	start := time.Now()
+	rt1.commitQueue <- CommitEvent{id: 1, timestamp: start}
+	rt2.commitQueue <- CommitEvent{id: 1, timestamp: start}
+
+	deadline := time.After(2 * time.Second)
+	for {
+		if rt1.stats.Snapshot().CompletedBuilds >= 1 && rt2.stats.Snapshot().CompletedBuilds >= 1 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for both services' builds to complete")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	elapsed := time.Since(start)
+
+	// With a single shared permit, two builds can't overlap: the second
+	// can't start until the first releases, so total time is at least
+	// 2x a single build's duration. A generous margin avoids flakiness.
+	if elapsed < 2*buildDuration-50*time.Millisecond {
+		t.Errorf("both builds completed in %v, want >= ~%v if serialized by the shared semaphore", elapsed, 2*buildDuration)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// eventSinkBufferSize is the capacity of the bounded in-memory buffer sitting
+// in front of every EventSink implementation below.
+const eventSinkBufferSize = 256
+
+// newEventSink builds the --eventSink implementation named by kind, wrapped
+// in a bufferedSink so a slow or unreachable consumer never blocks the
+// simulation. Returns (nil, nil) when kind is empty, matching the default
+// --eventSink="" (no sink configured).
+func newEventSink(kind, webhookURL, webhookSecret, natsURL, natsSubject, amqpURL, amqpExchange string) (*bufferedSink, error) {
+	switch kind {
+	case "":
+		return nil, nil
+	case "webhook":
+		if webhookURL == "" {
+			return nil, fmt.Errorf("--webhookURL is required when --eventSink=webhook")
+		}
+		return newBufferedSink(newWebhookSink(webhookURL, webhookSecret), eventSinkBufferSize), nil
+	case "nats":
+		if natsURL == "" {
+			return nil, fmt.Errorf("--natsURL is required when --eventSink=nats")
+		}
+		sink, err := newNATSSink(natsURL, natsSubject)
+		if err != nil {
+			return nil, err
+		}
+		return newBufferedSink(sink, eventSinkBufferSize), nil
+	case "amqp":
+		if amqpURL == "" {
+			return nil, fmt.Errorf("--amqpURL is required when --eventSink=amqp")
+		}
+		sink, err := newAMQPSink(amqpURL, amqpExchange)
+		if err != nil {
+			return nil, err
+		}
+		return newBufferedSink(sink, eventSinkBufferSize), nil
+	default:
+		return nil, fmt.Errorf("unknown --eventSink %q (want webhook, nats, or amqp)", kind)
+	}
+}
+
+// webhookSinkRetries is how many times a webhook POST is retried on failure
+// before Publish gives up and reports an error (logged by bufferedSink).
+const webhookSinkRetries = 3
+
+// webhookSink POSTs each Event as JSON to a configured URL, signing the body
+// with HMAC-SHA256 so the receiver can verify it came from this simulator.
+type webhookSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// newWebhookSink builds a webhookSink for --eventSink=webhook. secret may be
+// empty, in which case the signature header is omitted.
+func newWebhookSink(url, secret string) *webhookSink {
+	return &webhookSink{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Publish POSTs e to the webhook URL, retrying with exponential backoff on
+// failure.
+func (w *webhookSink) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= webhookSinkRetries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if lastErr = w.post(ctx, body); lastErr == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("webhook POST failed after %d attempts: %w", webhookSinkRetries+1, lastErr)
+}
+
+func (w *webhookSink) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		req.Header.Set("X-Promoter-Demo-Signature-256", "sha256="+signHMACSHA256(body, w.secret))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signHMACSHA256 returns the hex-encoded HMAC-SHA256 of body keyed by secret.
+func signHMACSHA256(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// natsSink publishes each Event as JSON to a subject on a NATS server.
+type natsSink struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// newNATSSink connects to a NATS server for --eventSink=nats.
+func newNATSSink(url, subject string) (*natsSink, error) {
+	if subject == "" {
+		subject = "promoter.demo.events"
+	}
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS server %s: %w", url, err)
+	}
+	return &natsSink{conn: conn, subject: subject}, nil
+}
+
+// Publish publishes e to the configured subject.
+func (s *natsSink) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return s.conn.Publish(s.subject, body)
+}
+
+// amqpSink publishes each Event as JSON to a RabbitMQ fanout exchange.
+type amqpSink struct {
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	exchange string
+}
+
+// newAMQPSink connects to a RabbitMQ server and declares exchange (a fanout
+// exchange, created if it doesn't already exist) for --eventSink=amqp.
+func newAMQPSink(url, exchange string) (*amqpSink, error) {
+	if exchange == "" {
+		exchange = "promoter.demo.events"
+	}
+
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to AMQP server %s: %w", url, err)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open AMQP channel: %w", err)
+	}
+
+	if err := channel.ExchangeDeclare(exchange, "fanout", true, false, false, false, nil); err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare AMQP exchange %s: %w", exchange, err)
+	}
+
+	return &amqpSink{conn: conn, channel: channel, exchange: exchange}, nil
+}
+
+// Publish publishes e to the configured exchange.
+func (s *amqpSink) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return s.channel.PublishWithContext(ctx, s.exchange, "", false, false, amqp.Publishing{
+		ContentType: "application/json",
+		Timestamp:   e.Timestamp,
+		Body:        body,
+	})
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// buildDurationBuckets are the histogram bucket upper bounds (seconds) used
+// for promoter_demo_build_duration_seconds. They span a few seconds up to an
+// hour since simulated builds are usually configured in minutes.
+var buildDurationBuckets = []float64{5, 15, 30, 60, 120, 300, 600, 900, 1800, 3600}
+
+// CompletedBuild is a record of a single finished build, kept around so the
+// HTTP dashboard can show recent history.
+type CompletedBuild struct {
+	CommitID int
+	Duration time.Duration
+	Finished time.Time
+}
+
+// maxRecentBuilds bounds the in-memory history shown on the dashboard.
+const maxRecentBuilds = 20
+
+// Registry is the single source of truth for simulation statistics. Both the
+// periodic text printer (printStats) and the /metrics HTTP endpoint read
+// from it so the two never drift apart.
+type Registry struct {
+	mu sync.Mutex
+
+	commitsTotal         int
+	queuedCommits        int
+	buildsCompletedTotal int
+	buildsAbortedTotal   int
+	buildsFailedTotal    int
+	retriesTotal         int
+
+	currentBuildStartTime *time.Time
+	currentBuildCommitID  int
+
+	durationBucketCounts []int
+	durationSum          float64
+	durationCount        int
+
+	recentBuilds []CompletedBuild
+}
+
+// NewRegistry returns an empty, ready-to-use Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		durationBucketCounts: make([]int, len(buildDurationBuckets)),
+	}
+}
+
+// IncCommits increments the total commits counter.
+func (r *Registry) IncCommits() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commitsTotal++
+}
+
+// SetQueueDepth sets the current queue depth gauge.
+func (r *Registry) SetQueueDepth(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queuedCommits = n
+}
+
+// IncQueueDepth adjusts the queue depth gauge by delta (positive or negative).
+func (r *Registry) IncQueueDepth(delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queuedCommits += delta
+}
+
+// StartBuild records that a build has started for the given commit.
+func (r *Registry) StartBuild(commitID int, start time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.currentBuildStartTime = &start
+	r.currentBuildCommitID = commitID
+}
+
+// ClearCurrentBuild clears the in-progress build gauge, regardless of how
+// the build ended.
+func (r *Registry) ClearCurrentBuild() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.currentBuildStartTime = nil
+	r.currentBuildCommitID = 0
+}
+
+// RecordAbort increments the aborted-builds counter.
+func (r *Registry) RecordAbort() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buildsAbortedTotal++
+}
+
+// RecordFailure increments the failed-builds counter for a build that
+// exhausted its retries (or hard-failed outright).
+func (r *Registry) RecordFailure() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buildsFailedTotal++
+}
+
+// RecordRetry increments the retry counter. Called once per retry attempt,
+// not per build.
+func (r *Registry) RecordRetry() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retriesTotal++
+}
+
+// RecordCompletion increments the completed-builds counter, observes the
+// build's wall-clock duration, and appends it to the recent-builds history.
+func (r *Registry) RecordCompletion(commitID int, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buildsCompletedTotal++
+	r.observeDurationLocked(duration.Seconds())
+
+	r.recentBuilds = append(r.recentBuilds, CompletedBuild{
+		CommitID: commitID,
+		Duration: duration,
+		Finished: time.Now(),
+	})
+	if len(r.recentBuilds) > maxRecentBuilds {
+		r.recentBuilds = r.recentBuilds[len(r.recentBuilds)-maxRecentBuilds:]
+	}
+}
+
+// Observe records a raw build-duration sample in the histogram, independent
+// of RecordCompletion bookkeeping. Exposed so callers that manage completion
+// timing themselves can feed the histogram directly.
+func (r *Registry) Observe(seconds float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observeDurationLocked(seconds)
+}
+
+func (r *Registry) observeDurationLocked(seconds float64) {
+	r.durationSum += seconds
+	r.durationCount++
+	for i, bound := range buildDurationBuckets {
+		if seconds <= bound {
+			r.durationBucketCounts[i]++
+		}
+	}
+}
+
+// Snapshot is a point-in-time, lock-free copy of the registry's state.
+type Snapshot struct {
+	TotalCommits          int
+	QueuedCommits         int
+	CompletedBuilds       int
+	AbortedBuilds         int
+	FailedBuilds          int
+	Retries               int
+	CurrentBuildCommitID  int
+	CurrentBuildStartTime *time.Time
+	RecentBuilds          []CompletedBuild
+}
+
+// Snapshot returns a copy of the current stats for display or serialization.
+func (r *Registry) Snapshot() Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recent := make([]CompletedBuild, len(r.recentBuilds))
+	copy(recent, r.recentBuilds)
+
+	return Snapshot{
+		TotalCommits:          r.commitsTotal,
+		QueuedCommits:         r.queuedCommits,
+		CompletedBuilds:       r.buildsCompletedTotal,
+		AbortedBuilds:         r.buildsAbortedTotal,
+		FailedBuilds:          r.buildsFailedTotal,
+		Retries:               r.retriesTotal,
+		CurrentBuildCommitID:  r.currentBuildCommitID,
+		CurrentBuildStartTime: r.currentBuildStartTime,
+		RecentBuilds:          recent,
+	}
+}
+
+// Restore overwrites the registry's counters and recent-build history from a
+// previously captured Snapshot, used to rehydrate a --stateFile checkpoint
+// on startup. It does not restore the raw build-duration histogram buckets,
+// since Snapshot doesn't carry them; the /metrics histogram simply starts
+// fresh after a resume.
+func (r *Registry) Restore(s Snapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.commitsTotal = s.TotalCommits
+	r.queuedCommits = s.QueuedCommits
+	r.buildsCompletedTotal = s.CompletedBuilds
+	r.buildsAbortedTotal = s.AbortedBuilds
+	r.buildsFailedTotal = s.FailedBuilds
+	r.retriesTotal = s.Retries
+	r.currentBuildCommitID = s.CurrentBuildCommitID
+	r.currentBuildStartTime = s.CurrentBuildStartTime
+	r.recentBuilds = append([]CompletedBuild(nil), s.RecentBuilds...)
+}
+
+// WriteMetricSamples renders this registry's samples, labeled with the given
+// service name, in Prometheus text exposition format. HELP/TYPE lines are
+// not emitted here since Prometheus wants them once per metric family, not
+// once per label set; see Aggregate.WriteMetrics for the full document.
+func (r *Registry) WriteMetricSamples(w io.Writer, service string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lines := []string{
+		fmt.Sprintf(`promoter_demo_commits_total{service="%s"} %d`, service, r.commitsTotal),
+		fmt.Sprintf(`promoter_demo_builds_completed_total{service="%s"} %d`, service, r.buildsCompletedTotal),
+		fmt.Sprintf(`promoter_demo_builds_aborted_total{service="%s"} %d`, service, r.buildsAbortedTotal),
+		fmt.Sprintf(`promoter_demo_builds_failed_total{service="%s"} %d`, service, r.buildsFailedTotal),
+		fmt.Sprintf(`promoter_demo_build_retries_total{service="%s"} %d`, service, r.retriesTotal),
+		fmt.Sprintf(`promoter_demo_queue_depth{service="%s"} %d`, service, r.queuedCommits),
+	}
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return err
+		}
+	}
+
+	for i, bound := range buildDurationBuckets {
+		if _, err := fmt.Fprintf(w, "promoter_demo_build_duration_seconds_bucket{service=\"%s\",le=\"%g\"} %d\n", service, bound, r.durationBucketCounts[i]); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "promoter_demo_build_duration_seconds_bucket{service=\"%s\",le=\"+Inf\"} %d\n", service, r.durationCount); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "promoter_demo_build_duration_seconds_sum{service=\"%s\"} %g\n", service, r.durationSum); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "promoter_demo_build_duration_seconds_count{service=\"%s\"} %d\n", service, r.durationCount); err != nil {
+		return err
+	}
+
+	return nil
+}
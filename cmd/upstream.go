@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pollUpstreamCommits is the --commitSource=upstream commit generator: it
+// fans out every newly-discovered commit to every service's commitQueue (one
+// upstream repo is assumed to drive all services in a given simulation run).
+func pollUpstreamCommits(runtimes []*serviceRuntime, repoURL, branch string, interval time.Duration, cacheDir string) {
+	clonePath, err := ensureBareClone(cacheDir, repoURL)
+	if err != nil {
+		fmt.Printf("❌ [upstream] Could not prepare cache clone of %s: %v\n", repoURL, err)
+		return
+	}
+
+	lastSHA, err := readLastSHA(cacheDir, repoURL)
+	if err != nil {
+		fmt.Printf("⚠️  [upstream] Could not read cached last-seen SHA: %v\n", err)
+	}
+
+	// IDs start at 2, as in synthetic mode where 1 is each service's
+	// synthesized initial commit (none is synthesized here). A resumed
+	// --stateFile checkpoint may have already advanced some runtimes past 2;
+	// since every runtime receives the same fanned-out commits, take the
+	// largest restored nextCommitID so a resumed run never reassigns an ID
+	// already used before the crash.
+	nextID := 2
+	for _, rt := range runtimes {
+		if _, _, n := rt.checkpointState(); n > nextID {
+			nextID = n
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		if err := gitFetch(clonePath, branch); err != nil {
+			fmt.Printf("⚠️  [upstream] git fetch failed: %v\n", err)
+			continue
+		}
+		newHead, err := gitRevParse(clonePath, "origin/"+branch)
+		if err != nil {
+			fmt.Printf("⚠️  [upstream] git rev-parse failed: %v\n", err)
+			continue
+		}
+
+		if lastSHA == "" {
+			// First poll: start tracking from the current tip rather than
+			// replaying the repo's entire history.
+			lastSHA = newHead
+			if err := writeLastSHA(cacheDir, repoURL, lastSHA); err != nil {
+				fmt.Printf("⚠️  [upstream] Could not persist last-seen SHA: %v\n", err)
+			}
+			continue
+		}
+
+		if newHead == lastSHA {
+			continue
+		}
+
+		commits, err := gitRevListCommits(clonePath, lastSHA, newHead)
+		if err != nil {
+			fmt.Printf("⚠️  [upstream] git rev-list failed: %v\n", err)
+			continue
+		}
+
+		for _, c := range commits {
+			event := CommitEvent{
+				timestamp: c.Date,
+				id:        nextID,
+				sha:       c.SHA,
+				author:    c.Author,
+				message:   c.Subject,
+			}
+			nextID++
+
+			shortSHA := c.SHA
+			if len(shortSHA) > 8 {
+				shortSHA = shortSHA[:8]
+			}
+			fmt.Printf("📝 [upstream] New commit detected: #%d %s (timestamp: %s)\n",
+				event.id, shortSHA, event.timestamp.Format("15:04:05"))
+
+			logReplayEvent(replayEvent{Type: "commit", Service: replayUpstreamService, CommitID: event.id, SHA: event.sha, Author: event.author, Message: event.message})
+			emitEvent(Event{Type: EventCommit, ServiceName: replayUpstreamService, CommitID: event.id, SHA: event.sha})
+
+			for _, rt := range runtimes {
+				rt.stats.IncCommits()
+				rt.stats.IncQueueDepth(1)
+				rt.commitQueue <- event
+				rt.enqueueSnapshot(event)
+				rt.setNextCommitID(nextID)
+			}
+			saveCheckpoint()
+		}
+
+		lastSHA = newHead
+		if err := writeLastSHA(cacheDir, repoURL, lastSHA); err != nil {
+			fmt.Printf("⚠️  [upstream] Could not persist last-seen SHA: %v\n", err)
+		}
+	}
+}
+
+// upstreamCommit is one commit discovered via git rev-list.
+type upstreamCommit struct {
+	SHA     string
+	Author  string
+	Subject string
+	Date    time.Time
+}
+
+// cacheKey returns a filesystem-safe identifier for a repo URL, used to name
+// its bare clone directory and last-seen-SHA file.
+func cacheKey(repoURL string) string {
+	sum := sha1.Sum([]byte(repoURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// ensureBareClone returns the path to a bare clone of repoURL under cacheDir,
+// cloning it if it doesn't already exist.
+func ensureBareClone(cacheDir, repoURL string) (string, error) {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	clonePath := filepath.Join(cacheDir, cacheKey(repoURL)+".git")
+	if _, err := os.Stat(clonePath); err == nil {
+		return clonePath, nil
+	}
+
+	cmd := exec.Command("git", "clone", "--bare", repoURL, clonePath)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git clone --bare failed: %w (stderr: %s)", err, stderr.String())
+	}
+
+	return clonePath, nil
+}
+
+func gitFetch(clonePath, branch string) error {
+	// A bare clone has no remote-tracking refs (git-clone(1): "neither
+	// remote-tracking branches nor the related configuration variables are
+	// created"), so fetching "branch" alone would only update FETCH_HEAD and
+	// leave "origin/branch" unresolvable. Fetch straight into that ref.
+	refspec := branch + ":refs/remotes/origin/" + branch
+	cmd := exec.Command("git", "--git-dir", clonePath, "fetch", "origin", refspec)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w (stderr: %s)", err, stderr.String())
+	}
+	return nil
+}
+
+func gitRevParse(clonePath, ref string) (string, error) {
+	cmd := exec.Command("git", "--git-dir", clonePath, "rev-parse", ref)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w (stderr: %s)", err, stderr.String())
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// gitRevListCommits returns the commits in (oldHead, newHead], oldest first,
+// so they're enqueued in the order they were made upstream.
+func gitRevListCommits(clonePath, oldHead, newHead string) ([]upstreamCommit, error) {
+	const sep = "\x1f"
+	format := strings.Join([]string{"%H", "%an <%ae>", "%s", "%cI"}, sep)
+
+	cmd := exec.Command("git", "--git-dir", clonePath, "rev-list", "--reverse",
+		"--pretty=format:"+format, oldHead+".."+newHead)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w (stderr: %s)", err, stderr.String())
+	}
+
+	var commits []upstreamCommit
+	for _, line := range strings.Split(out.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, sep)
+		if len(fields) != 4 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			date = time.Now()
+		}
+		commits = append(commits, upstreamCommit{
+			SHA:     fields[0],
+			Author:  fields[1],
+			Subject: fields[2],
+			Date:    date,
+		})
+	}
+
+	return commits, nil
+}
+
+func lastSHAPath(cacheDir, repoURL string) string {
+	return filepath.Join(cacheDir, cacheKey(repoURL)+".lastsha")
+}
+
+func readLastSHA(cacheDir, repoURL string) (string, error) {
+	data, err := os.ReadFile(lastSHAPath(cacheDir, repoURL))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// writeLastSHA persists the last-seen SHA via write-then-rename so a crash
+// mid-write can never leave a truncated/corrupt cache file behind.
+func writeLastSHA(cacheDir, repoURL, sha string) error {
+	path := lastSHAPath(cacheDir, repoURL)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(sha), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// runGit runs a git command in dir, failing the test on error.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+// newTestRepo creates a throwaway git repo with the given commit subjects
+// applied in order, returning the repo's path and the SHA before any of them
+// were made.
+func newTestRepo(t *testing.T, subjects []string) (repoPath string, oldHead string) {
+	t.Helper()
+	repoPath = t.TempDir()
+	runGit(t, repoPath, "init", "--initial-branch=main")
+	runGit(t, repoPath, "config", "user.email", "test@example.com")
+	runGit(t, repoPath, "config", "user.name", "Test User")
+
+	runGit(t, repoPath, "commit", "--allow-empty", "-m", "initial commit")
+	oldHead = runGit(t, repoPath, "rev-parse", "HEAD")
+	oldHead = oldHead[:len(oldHead)-1] // trim trailing newline
+
+	for _, subject := range subjects {
+		runGit(t, repoPath, "commit", "--allow-empty", "-m", subject)
+	}
+
+	return repoPath, oldHead
+}
+
+func TestGitRevListCommits(t *testing.T) {
+	subjects := []string{"first change", "second change: with a colon", "third change"}
+	repoPath, oldHead := newTestRepo(t, subjects)
+	newHead := runGit(t, repoPath, "rev-parse", "HEAD")
+	newHead = newHead[:len(newHead)-1]
+
+	commits, err := gitRevListCommits(filepath.Join(repoPath, ".git"), oldHead, newHead)
+	if err != nil {
+		t.Fatalf("gitRevListCommits: %v", err)
+	}
+
+	if len(commits) != len(subjects) {
+		t.Fatalf("got %d commits, want %d", len(commits), len(subjects))
+	}
+
+	for i, c := range commits {
+		if c.Subject != subjects[i] {
+			t.Errorf("commit %d subject = %q, want %q (oldest-first order)", i, c.Subject, subjects[i])
+		}
+		if len(c.SHA) != 40 {
+			t.Errorf("commit %d SHA = %q, want a 40-char hex SHA", i, c.SHA)
+		}
+		if c.Author == "" {
+			t.Errorf("commit %d has an empty author", i)
+		}
+	}
+}
+
+func TestGitRevListCommitsNoNewCommits(t *testing.T) {
+	repoPath, oldHead := newTestRepo(t, nil)
+
+	commits, err := gitRevListCommits(filepath.Join(repoPath, ".git"), oldHead, oldHead)
+	if err != nil {
+		t.Fatalf("gitRevListCommits: %v", err)
+	}
+	if len(commits) != 0 {
+		t.Errorf("got %d commits for an empty range, want 0", len(commits))
+	}
+}
+
+func TestEnsureBareCloneReusesExistingClone(t *testing.T) {
+	upstream, _ := newTestRepo(t, []string{"one"})
+	cacheDir := t.TempDir()
+
+	path1, err := ensureBareClone(cacheDir, upstream)
+	if err != nil {
+		t.Fatalf("ensureBareClone: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path1, "HEAD")); err != nil {
+		t.Fatalf("expected a bare clone at %s: %v", path1, err)
+	}
+
+	path2, err := ensureBareClone(cacheDir, upstream)
+	if err != nil {
+		t.Fatalf("ensureBareClone (second call): %v", err)
+	}
+	if path1 != path2 {
+		t.Errorf("ensureBareClone path changed between calls: %s vs %s", path1, path2)
+	}
+}
+
+// TestPollUpstreamCommitsPersistsNextCommitID verifies that nextID advances
+// the checkpointed nextCommitID as upstream commits are assigned IDs, not
+// just once at startup from a restored checkpoint. Otherwise a crash mid-run
+// resumes from a stale nextCommitID and reassigns IDs already recorded in the
+// same checkpoint's history.
+func TestPollUpstreamCommitsPersistsNextCommitID(t *testing.T) {
+	// pollUpstreamCommits has no shutdown hook and is left running in the
+	// background for the rest of the test binary's life, so its upstream
+	// repo and cache dir use manually-cleaned-up temp dirs (ignoring removal
+	// errors) rather than t.TempDir(), which would otherwise fail the test
+	// if a background poll tick races the automatic cleanup.
+	upstream, err := os.MkdirTemp("", "poll-upstream-repo")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(upstream) })
+	runGit(t, upstream, "init", "--initial-branch=main")
+	runGit(t, upstream, "config", "user.email", "test@example.com")
+	runGit(t, upstream, "config", "user.name", "Test User")
+	runGit(t, upstream, "commit", "--allow-empty", "-m", "initial commit")
+
+	cacheDir, err := os.MkdirTemp("", "poll-upstream-cache")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(cacheDir) })
+
+	rt := newTestRuntime(t, "svc1")
+
+	go pollUpstreamCommits([]*serviceRuntime{rt}, upstream, "main", 10*time.Millisecond, cacheDir)
+
+	// Wait for the first poll to establish a baseline SHA (it doesn't enqueue
+	// any commits) before pushing new ones.
+	deadline := time.After(2 * time.Second)
+	for {
+		if sha, _ := readLastSHA(cacheDir, upstream); sha != "" {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for the first poll to record a baseline SHA")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	runGit(t, upstream, "commit", "--allow-empty", "-m", "first upstream change")
+	runGit(t, upstream, "commit", "--allow-empty", "-m", "second upstream change")
+
+	deadline = time.After(2 * time.Second)
+	for {
+		if len(rt.commitQueue) >= 2 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for both upstream commits to be queued")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	_, _, gotNextID := rt.checkpointState()
+	if gotNextID != 4 {
+		t.Errorf("nextCommitID = %d, want 4 (2 synthetic-commit reservation + 2 processed upstream commits)", gotNextID)
+	}
+}